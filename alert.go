@@ -1,111 +1,160 @@
 package poller
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
-	"github.com/marcw/ezmail"
-	"github.com/marcw/pagerduty"
-	"net"
-	"net/smtp"
+	"net/http"
 	"os"
-	"strings"
 	"time"
 )
 
-// SMTP
-type smtpAlerter struct {
-	addr    string
-	auth    smtp.Auth
-	message ezmail.Message
+// PagerDuty
+const defaultPagerDutyEndpoint = "https://events.pagerduty.com/v2/enqueue"
+
+type pagerDutyAlerter struct {
+	routingKey string
+	endpoint   string
+	severity   string
+	source     string
+	client     *http.Client
+}
+
+// Events API v2 event
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     string                 `json:"timestamp"`
+	Component     string                 `json:"component"`
+	Class         string                 `json:"class"`
+	CustomDetails map[string]interface{} `json:"custom_details"`
 }
 
-func NewSmtpAlerter() (Alerter, error) {
-	envHost := os.Getenv("SMTP_HOST")
-	envPort := os.Getenv("SMTP_PORT")
-	envAuth := os.Getenv("SMTP_AUTH")
-	envUsername := os.Getenv("SMTP_USERNAME")
-	envPassword := os.Getenv("SMTP_PASSWORD")
-	envIdentity := os.Getenv("SMTP_PLAIN_IDENTITY")
-	envTo := os.Getenv("SMTP_RECIPIENT")
-	envFrom := os.Getenv("SMTP_FROM")
-
-	if envHost == "" {
-		return nil, fmt.Errorf("Please define SMTP_HOST env var")
+// PagerDuty alerter's options
+type PagerDutyOptions struct {
+	RoutingKey string // Integration key
+	Severity   string // Either critical, error, warning or info. Defaults to critical.
+	Source     string // Source of the events. Defaults to the hostname.
+	Endpoint   string // URL events are submitted to. Defaults to PagerDuty's.
+}
+
+// Instantiates an Alerter sending events to PagerDuty's Events API v2.
+// Incidents are triggered when a check is down, and resolved when it is back up.
+// It is configured with these environment variables:
+// * PAGERDUTY_ROUTING_KEY: (required) integration key. PAGERDUTY_SERVICE_KEY is used if it's not defined.
+// * PAGERDUTY_SEVERITY: (optional) critical, error, warning or info. Defaults to critical.
+// * PAGERDUTY_SOURCE: (optional) source of the events. Defaults to the hostname.
+// * PAGERDUTY_ENDPOINT: (optional) URL events are submitted to. Defaults to PagerDuty's.
+// * PAGERDUTY_MAX_ATTEMPTS: (optional) deliveries attempted per alert, see DefaultRetryPolicy. 1 disables retries.
+func NewPagerDutyAlerter() (Alerter, error) {
+	envRoutingKey := os.Getenv("PAGERDUTY_ROUTING_KEY")
+	if envRoutingKey == "" {
+		envRoutingKey = os.Getenv("PAGERDUTY_SERVICE_KEY")
 	}
-	if envPort == "" {
-		return nil, fmt.Errorf("Please define SMTP_PORT env var")
+	if envRoutingKey == "" {
+		return nil, fmt.Errorf("Please define the PAGERDUTY_ROUTING_KEY environment variable.")
 	}
 
-	if envAuth != "" && envAuth != "MD5" && envAuth != "PLAIN" {
-		return nil, fmt.Errorf("Please either leave SMTP_AUTH env empty or set it to MD5 or PLAIN")
+	envSeverity := os.Getenv("PAGERDUTY_SEVERITY")
+	if envSeverity != "" && !isPagerDutySeverity(envSeverity) {
+		return nil, fmt.Errorf("Please either leave PAGERDUTY_SEVERITY env empty or set it to critical, error, warning or info")
 	}
 
-	var addr string
-	if envPort != "" {
-		addr = net.JoinHostPort(envHost, envPort)
-	} else {
-		addr = envHost
+	alerter, err := NewPagerDutyAlerterWithOptions(PagerDutyOptions{
+		RoutingKey: envRoutingKey,
+		Severity:   envSeverity,
+		Source:     os.Getenv("PAGERDUTY_SOURCE"),
+		Endpoint:   os.Getenv("PAGERDUTY_ENDPOINT")})
+	if err != nil {
+		return nil, err
 	}
 
-	var auth smtp.Auth
-	if envAuth == "MD5" {
-		auth = smtp.CRAMMD5Auth(envUsername, envPassword)
-	} else if envAuth == "PLAIN" {
-		auth = smtp.PlainAuth(envIdentity, envUsername, envPassword, envHost)
-	}
+	return withEnvRetryPolicy(alerter, "PAGERDUTY_MAX_ATTEMPTS")
+}
 
-	message := ezmail.NewMessage()
-	message.SetFrom("", envFrom)
-	for _, v := range strings.Split(envTo, ";") {
-		message.AddTo("", v)
+// Instantiates an Alerter sending events to PagerDuty's Events API v2 according to options.
+// Failed deliveries are not retried, see NewRetryAlerter.
+func NewPagerDutyAlerterWithOptions(options PagerDutyOptions) (Alerter, error) {
+	if options.RoutingKey == "" {
+		return nil, fmt.Errorf("PagerDuty routing key cannot be empty")
+	}
+	if options.Severity == "" {
+		options.Severity = "critical"
+	}
+	if !isPagerDutySeverity(options.Severity) {
+		return nil, fmt.Errorf("PagerDuty severity should either be critical, error, warning or info")
+	}
+	if options.Source == "" {
+		options.Source, _ = os.Hostname()
+	}
+	if options.Endpoint == "" {
+		options.Endpoint = defaultPagerDutyEndpoint
 	}
 
-	smtp := &smtpAlerter{
-		addr:    addr,
-		auth:    auth,
-		message: *message}
+	return &pagerDutyAlerter{
+		routingKey: options.RoutingKey,
+		endpoint:   options.Endpoint,
+		severity:   options.Severity,
+		source:     options.Source,
+		client:     &http.Client{Timeout: 10 * time.Second}}, nil
+}
 
-	return smtp, nil
+func isPagerDutySeverity(severity string) bool {
+	return severity == "critical" || severity == "error" || severity == "warning" || severity == "info"
 }
 
-func (m *smtpAlerter) Alert(event *Event) {
-	msg := m.message
-	msg.Subject = fmt.Sprintf("[ALERT] %s is down", event.Check.Key)
-	msg.Body = fmt.Sprintf("Poller alert: %s is down since %s", event.Check.AlertDescription(), event.Check.DownSince.Format(time.RFC822))
+// Returns the event to submit to PagerDuty: a trigger if the check is down, a resolve if it is back up.
+// The check's key is used as deduplication key so that both refer to the same incident.
+func (pda *pagerDutyAlerter) event(event *Event) *pagerDutyEvent {
+	if event.IsUp() {
+		return &pagerDutyEvent{RoutingKey: pda.routingKey, EventAction: "resolve", DedupKey: event.Check.Key}
+	}
 
-	if err := smtp.SendMail(m.addr, m.auth, msg.From.String(), msg.Recipients(), msg.Bytes()); err != nil {
-		println(err)
+	details := map[string]interface{}{
+		"checked_at":  event.Time.Format(time.RFC3339),
+		"duration":    event.Duration.String(),
+		"status_code": event.StatusCode,
+		"was_up_for":  event.Check.WasUpFor.String()}
+	for k, v := range event.Check.Tags {
+		details["tag_"+k] = v
+	}
+	for k, v := range event.Details {
+		details[k] = v
 	}
-}
 
-// PagerDuty
-type pagerDutyAlerter struct {
-	serviceKey string
+	return &pagerDutyEvent{
+		RoutingKey:  pda.routingKey,
+		EventAction: "trigger",
+		DedupKey:    event.Check.Key,
+		Payload: &pagerDutyPayload{
+			Summary:       fmt.Sprintf("%s is DOWN since %s.", event.Check.AlertDescription(), event.Check.DownSince.Format(time.RFC3339)),
+			Source:        pda.source,
+			Severity:      pda.severity,
+			Timestamp:     event.Time.Format(time.RFC3339),
+			Component:     event.Check.Key,
+			Class:         string(event.Check.Type()),
+			CustomDetails: details}}
 }
 
-func NewPagerDutyAlerter() (Alerter, error) {
-	envServiceKey := os.Getenv("PAGERDUTY_SERVICE_KEY")
-	if envServiceKey == "" {
-		return nil, fmt.Errorf("Please define the PAGERDUTY_SERVICE_KEY environment variable.")
+func (pda *pagerDutyAlerter) Alert(event *Event) error {
+	data, err := json.Marshal(pda.event(event))
+	if err != nil {
+		return permanentAlertErrorf("Unable to encode PagerDuty event: %s", err)
 	}
 
-	return &pagerDutyAlerter{envServiceKey}, nil
-}
-
-func (pda *pagerDutyAlerter) Alert(event *Event) {
-	description := fmt.Sprintf("%s is DOWN since %s.", event.Check.AlertDescription(), event.Check.DownSince.Format(time.RFC3339))
-	e := pagerduty.NewTriggerEvent(pda.serviceKey, description)
-	e.Details["checked_at"] = event.Time.Format(time.RFC3339)
-	e.Details["duration"] = event.Duration.String()
-	e.Details["status_code"] = event.StatusCode
-	e.Details["was_up_for"] = event.Check.WasUpFor.String()
-	e.IncidentKey = event.Check.Key
-	for {
-		_, statusCode, _ := pagerduty.Submit(e)
-		if statusCode < 500 {
-			break
-		} else {
-			// Wait a bit before trying again
-			time.Sleep(3 * time.Second)
-		}
+	resp, err := pda.client.Post(pda.endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
 	}
+	resp.Body.Close()
+
+	return httpAlertError("PagerDuty", resp.StatusCode)
 }
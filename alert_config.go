@@ -0,0 +1,266 @@
+package poller
+
+import (
+	"fmt"
+	"github.com/bitly/go-simplejson"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// An AlerterConfigurator instantiates an Alerter from the JSON definition of a named alerter.
+type AlerterConfigurator func(*simplejson.Json) (Alerter, error)
+
+var alerterConfiguratorsMu sync.RWMutex
+var alerterConfigurators = map[string]AlerterConfigurator{
+	"smtp":      readSmtpAlerterConfig,
+	"pagerduty": readPagerDutyAlerterConfig,
+	"webhook":   readWebhookAlerterConfig}
+
+// RegisterAlerterConfigurator makes NewAlerterFromJSON instantiate alerters of type t with configurator.
+// Registering a configurator for an existing type replaces it.
+func RegisterAlerterConfigurator(t string, configurator AlerterConfigurator) {
+	alerterConfiguratorsMu.Lock()
+	defer alerterConfiguratorsMu.Unlock()
+
+	alerterConfigurators[t] = configurator
+}
+
+// A namedAlerter sends the alerts of a check to the alerters it references
+type namedAlerter struct {
+	alerters map[string]Alerter
+	defaults []string
+}
+
+// Instantiates an Alerter which sends the alerts of a check to the alerters referenced by its Alerters
+// field, or to the defaults alerters if it references none. Events of escalated checks are sent to the
+// alerters of their escalation steps instead.
+// An error is returned if a default alerter is unknown.
+func NewNamedAlerter(alerters map[string]Alerter, defaults ...string) (Alerter, error) {
+	for _, name := range defaults {
+		if _, ok := alerters[name]; !ok {
+			return nil, fmt.Errorf("Unknown default alerter %s", name)
+		}
+	}
+
+	return &namedAlerter{alerters: alerters, defaults: defaults}, nil
+}
+
+// Alert sends the event to the alerters of its check concurrently and returns once all of them are done.
+// Alerters which are unknown are reported as a failure, the event is still sent to the known ones.
+func (n *namedAlerter) Alert(event *Event) error {
+	names := referencedAlerters(event)
+	if len(names) == 0 {
+		names = n.defaults
+	}
+
+	alerters := make([]Alerter, 0, len(names))
+	for _, name := range names {
+		alerter, ok := n.alerters[name]
+		if !ok {
+			alerter = &unknownAlerter{name}
+		}
+		alerters = append(alerters, alerter)
+	}
+
+	return alertAll(alerters, event)
+}
+
+// HasAlerter returns true if an alerter is named name
+func (n *namedAlerter) HasAlerter(name string) bool {
+	_, ok := n.alerters[name]
+	return ok
+}
+
+// Returns the names of the alerters the event should be sent to: the alerters of the escalation steps
+// which became due, or else the alerters referenced by its check. Returns nil if it references none.
+func referencedAlerters(event *Event) []string {
+	if len(event.Alerters) > 0 {
+		return event.Alerters
+	}
+
+	return event.Check.Alerters
+}
+
+// An unknownAlerter fails every alert, for the checks referencing an alerter that does not exist
+type unknownAlerter struct {
+	name string
+}
+
+func (u *unknownAlerter) Alert(event *Event) error {
+	return permanentAlertErrorf("Check %s references unknown alerter %s", event.Check.Key, u.name)
+}
+
+// NewAlerterFromJSON instantiates the named alerters defined in data, and returns an Alerter
+// dispatching the alerts of each check to the alerters it references (see NewNamedAlerter).
+// data is a JSON object mapping names to alerter definitions. Each definition has a "type"
+// (smtp, pagerduty, webhook or any registered type) and a "default" flag which makes the alerter
+// receive the alerts of checks referencing no alerter. Failed deliveries are only retried if the
+// definition has a "retry" policy (see readRetryConfig). The other fields are type specific.
+func NewAlerterFromJSON(data []byte) (Alerter, error) {
+	js, err := simplejson.NewJson(data)
+	if err != nil {
+		return nil, err
+	}
+	definitions, err := js.Map()
+	if err != nil {
+		return nil, fmt.Errorf("Alerters should be defined in a JSON object.")
+	}
+
+	// Sorted so that errors and defaults do not depend on the map's order
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	alerters := make(map[string]Alerter)
+	defaults := make([]string, 0)
+	for _, name := range names {
+		definition := js.Get(name)
+
+		alerterType, err := definition.Get("type").String()
+		if err != nil {
+			return nil, fmt.Errorf("Alerter %s has no type", name)
+		}
+
+		alerterConfiguratorsMu.RLock()
+		configurator, ok := alerterConfigurators[alerterType]
+		alerterConfiguratorsMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("Alerter %s has an unknown type %s", name, alerterType)
+		}
+
+		if alerters[name], err = configurator(definition); err != nil {
+			return nil, fmt.Errorf("Alerter %s: %s", name, err)
+		}
+		if retry, ok := definition.CheckGet("retry"); ok {
+			if alerters[name], err = readRetryConfig(alerters[name], retry); err != nil {
+				return nil, fmt.Errorf("Alerter %s: %s", name, err)
+			}
+		}
+		if definition.Get("default").MustBool() {
+			defaults = append(defaults, name)
+		}
+	}
+
+	return NewNamedAlerter(alerters, defaults...)
+}
+
+// Wraps alerter with the retry policy defined in js, ie: {"maxAttempts": 5, "initialBackoff": "1s",
+// "maxBackoff": "30s", "jitter": 0.2, "deadLetterFile": "/var/log/poller/alerts.log"}.
+// Missing fields default to DefaultRetryPolicy's. Undeliverable alerts are appended to deadLetterFile, if defined.
+func readRetryConfig(alerter Alerter, js *simplejson.Json) (Alerter, error) {
+	policy, err := readRetryPolicy(js)
+	if err != nil {
+		return nil, err
+	}
+
+	var deadLetter io.Writer
+	if path := js.Get("deadLetterFile").MustString(); path != "" {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		deadLetter = file
+	}
+
+	return NewRetryAlerter(alerter, policy, deadLetter), nil
+}
+
+// Reads the retry policy defined in js. Missing fields default to DefaultRetryPolicy's.
+func readRetryPolicy(js *simplejson.Json) (RetryPolicy, error) {
+	policy := DefaultRetryPolicy
+	policy.MaxAttempts = js.Get("maxAttempts").MustInt(policy.MaxAttempts)
+	policy.Jitter = js.Get("jitter").MustFloat64(policy.Jitter)
+
+	var err error
+	if backoff := js.Get("initialBackoff").MustString(); backoff != "" {
+		if policy.InitialBackoff, err = time.ParseDuration(backoff); err != nil {
+			return policy, err
+		}
+	}
+	if backoff := js.Get("maxBackoff").MustString(); backoff != "" {
+		if policy.MaxBackoff, err = time.ParseDuration(backoff); err != nil {
+			return policy, err
+		}
+	}
+
+	return policy, nil
+}
+
+// Returns the string array of the field key of js, nil if it is not defined
+func readJSONStringArray(js *simplejson.Json, key string) ([]string, error) {
+	if _, ok := js.CheckGet(key); !ok {
+		return nil, nil
+	}
+	values, err := js.Get(key).StringArray()
+	if err != nil {
+		return nil, fmt.Errorf("%s can only accept string.", key)
+	}
+
+	return values, nil
+}
+
+func readSmtpAlerterConfig(js *simplejson.Json) (Alerter, error) {
+	options := SmtpOptions{
+		Host:            js.Get("host").MustString(),
+		Port:            js.Get("port").MustString(),
+		Auth:            js.Get("auth").MustString(),
+		Username:        js.Get("username").MustString(),
+		Password:        js.Get("password").MustString(),
+		Identity:        js.Get("identity").MustString(),
+		From:            js.Get("from").MustString(),
+		TLS:             js.Get("tls").MustString(),
+		CAFile:          js.Get("caFile").MustString(),
+		SubjectTemplate: js.Get("subjectTemplate").MustString(),
+		TextTemplate:    js.Get("textTemplate").MustString(),
+		HTMLTemplate:    js.Get("htmlTemplate").MustString()}
+
+	// port may be given as a number
+	if port, err := js.Get("port").Int(); err == nil {
+		options.Port = fmt.Sprintf("%d", port)
+	}
+
+	var err error
+	if options.To, err = readJSONStringArray(js, "to"); err != nil {
+		return nil, err
+	}
+
+	if delay := js.Get("digestDelay").MustString(); delay != "" {
+		if options.DigestDelay, err = time.ParseDuration(delay); err != nil {
+			return nil, err
+		}
+	}
+	// Digests are retried as a whole, the alerts of a failed digest are then not retried one by one
+	if retry, ok := js.CheckGet("retry"); ok && options.DigestDelay > 0 {
+		if options.DigestRetryPolicy, err = readRetryPolicy(retry); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewSmtpAlerterWithOptions(options)
+}
+
+func readPagerDutyAlerterConfig(js *simplejson.Json) (Alerter, error) {
+	return NewPagerDutyAlerterWithOptions(PagerDutyOptions{
+		RoutingKey: js.Get("routingKey").MustString(),
+		Severity:   js.Get("severity").MustString(),
+		Source:     js.Get("source").MustString(),
+		Endpoint:   js.Get("endpoint").MustString()})
+}
+
+func readWebhookAlerterConfig(js *simplejson.Json) (Alerter, error) {
+	headers := make(map[string]string)
+	for k, v := range js.Get("headers").MustMap() {
+		value, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("Headers can only accept string.")
+		}
+		headers[k] = value
+	}
+
+	return NewWebhookAlerter(js.Get("url").MustString(), js.Get("template").MustString(), headers, js.Get("secret").MustString())
+}
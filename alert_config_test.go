@@ -0,0 +1,130 @@
+package poller
+
+import (
+	"fmt"
+	"github.com/bitly/go-simplejson"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewAlerterFromJSON(t *testing.T) {
+	received := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- r.URL.Path + " " + string(body)
+	}))
+	defer server.Close()
+
+	alerter, err := NewAlerterFromJSON([]byte(fmt.Sprintf(`{
+		"chat-web": {"type": "webhook", "url": "%[1]s/web", "template": "{{.Check.Key}}"},
+		"chat-ops": {"type": "webhook", "url": "%[1]s/ops", "template": "{{.Check.Key}}", "default": true},
+		"smtp-oncall": {"type": "smtp", "host": "localhost", "port": 25, "from": "poller@example.org", "to": ["ops@example.org"]},
+		"pagerduty-web": {"type": "pagerduty", "routingKey": "foobar", "endpoint": "%[1]s/pagerduty"}
+	}`, server.URL)))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	// Checks referencing no alerter are sent to the default ones
+	check, _ := NewCheck("website", "10s", false, "", false, make(map[string]interface{}))
+	if err := alerter.Alert(NewEvent(check)); err != nil {
+		t.Error(err)
+	}
+	if got := <-received; got != "/ops website" {
+		t.Errorf("Alert should be sent to chat-ops. Got %s", got)
+	}
+
+	check.Alerters = []string{"chat-web"}
+	if err := alerter.Alert(NewEvent(check)); err != nil {
+		t.Error(err)
+	}
+	if got := <-received; got != "/web website" {
+		t.Errorf("Alert should be sent to chat-web. Got %s", got)
+	}
+
+	// Unknown alerters are reported, known ones still receive the alert
+	check.Alerters = []string{"chat-web", "foobar"}
+	if err := alerter.Alert(NewEvent(check)); err == nil {
+		t.Error("Referencing an unknown alerter should fail")
+	}
+	if got := <-received; got != "/web website" {
+		t.Errorf("Alert should be sent to chat-web. Got %s", got)
+	}
+
+	// Escalated events are sent to the alerters of their escalation steps
+	event := NewEvent(check)
+	event.Alerters = []string{"chat-ops"}
+	if err := alerter.Alert(event); err != nil {
+		t.Error(err)
+	}
+	if got := <-received; got != "/ops website" {
+		t.Errorf("Alert should be sent to chat-ops. Got %s", got)
+	}
+
+	smtp := alerter.(*namedAlerter).alerters["smtp-oncall"].(*smtpAlerter)
+	if smtp.addr != "localhost:25" {
+		t.Errorf("SMTP address is wrong. Got %s", smtp.addr)
+	}
+}
+
+func TestNewAlerterFromJSONErrors(t *testing.T) {
+	for _, data := range []string{
+		`[]`,
+		`{"foo": {"url": "http://localhost"}}`,
+		`{"foo": {"type": "foobar"}}`,
+		`{"foo": {"type": "webhook"}}`,
+		`{"foo": {"type": "pagerduty", "routingKey": "foobar", "severity": "foobar"}}`,
+		`{"foo": {"type": "smtp", "host": "localhost", "from": "poller@example.org", "to": [42]}}`} {
+		if _, err := NewAlerterFromJSON([]byte(data)); err == nil {
+			t.Errorf("%s should be rejected", data)
+		}
+	}
+
+	RegisterAlerterConfigurator("foobar", func(js *simplejson.Json) (Alerter, error) {
+		return &failingTestAlerter{}, nil
+	})
+	defer func() {
+		alerterConfiguratorsMu.Lock()
+		delete(alerterConfigurators, "foobar")
+		alerterConfiguratorsMu.Unlock()
+	}()
+	if _, err := NewAlerterFromJSON([]byte(`{"foo": {"type": "foobar"}}`)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewAlerterFromJSONRetry(t *testing.T) {
+	deadLetterFile := filepath.Join(t.TempDir(), "alerts.log")
+	alerter, err := NewAlerterFromJSON([]byte(fmt.Sprintf(`{
+		"chat": {"type": "webhook", "url": "http://localhost", "retry": {"maxAttempts": 3, "initialBackoff": "10ms", "deadLetterFile": %q}},
+		"email": {"type": "smtp", "host": "localhost", "from": "poller@example.org", "to": ["ops@example.org"]}
+	}`, deadLetterFile)))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	retry, ok := alerter.(*namedAlerter).alerters["chat"].(*retryAlerter)
+	if !ok {
+		t.Log("Alerters defining a retry policy should be wrapped")
+		t.FailNow()
+	}
+	if retry.policy.MaxAttempts != 3 || retry.policy.InitialBackoff != 10*time.Millisecond || retry.policy.MaxBackoff != DefaultRetryPolicy.MaxBackoff {
+		t.Errorf("Retry policy is wrong. Got %v", retry.policy)
+	}
+	if retry.deadLetter == nil {
+		t.Error("Dead letters should be written to deadLetterFile")
+	}
+	if _, ok := alerter.(*namedAlerter).alerters["email"].(*smtpAlerter); !ok {
+		t.Error("Alerters without a retry policy should not be wrapped")
+	}
+
+	if _, err := NewAlerterFromJSON([]byte(`{"chat": {"type": "webhook", "url": "http://localhost", "retry": {"maxBackoff": "foo"}}}`)); err == nil {
+		t.Error("An invalid retry policy should be rejected")
+	}
+}
@@ -0,0 +1,149 @@
+package poller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A RetryPolicy defines how many times and how often the delivery of an alert is attempted.
+// The delay before the nth retry is InitialBackoff * 2^(n-1), capped to MaxBackoff, and randomized
+// by plus or minus Jitter (a fraction of the delay, between 0 and 1).
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Jitter         float64
+}
+
+// Suggested policy for NewRetryAlerter
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: 30 * time.Second, Jitter: 0.2}
+
+// Returns the delay before the given retry, starting at 1
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < retry && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(delay))
+	}
+
+	return delay
+}
+
+// A permanentAlertError is an alert delivery error which retrying would not fix, ie: a rejected payload.
+type permanentAlertError struct {
+	err error
+}
+
+func (e *permanentAlertError) Error() string {
+	return e.err.Error()
+}
+
+// Marks err as permanent: its alert will not be retried
+func permanentAlertErrorf(format string, a ...interface{}) error {
+	return &permanentAlertError{fmt.Errorf(format, a...)}
+}
+
+// Returns the error matching the status code of an HTTP alert delivery, or nil on success.
+// Client errors are permanent, except for 429 Too Many Requests.
+func httpAlertError(service string, statusCode int) error {
+	if statusCode < 300 {
+		return nil
+	}
+	if statusCode >= 400 && statusCode < 500 && statusCode != 429 {
+		return permanentAlertErrorf("%s rejected the alert with status code %d", service, statusCode)
+	}
+
+	return fmt.Errorf("%s responded with status code %d", service, statusCode)
+}
+
+// Record written to the dead letter log for each undeliverable alert
+type deadLetter struct {
+	Event    *jsonEvent `json:"event"`
+	Error    string     `json:"error"`
+	Attempts int        `json:"attempts"`
+	Time     time.Time  `json:"time"`
+}
+
+// A retryAlerter delivers alerts through another Alerter, retrying according to a RetryPolicy
+type retryAlerter struct {
+	alerter    Alerter
+	policy     RetryPolicy
+	deadLetter io.Writer
+	mu         sync.Mutex // serializes writes to the dead letter log
+	sleep      func(time.Duration)
+}
+
+// Instantiates an Alerter which delivers alerts through alerter, and retries failed deliveries according to policy.
+// Once every attempt failed, or if the error is permanent, the alert is written as a JSON object to the
+// deadLetter log, if not nil, and the error is returned.
+func NewRetryAlerter(alerter Alerter, policy RetryPolicy, deadLetter io.Writer) Alerter {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 1
+	}
+
+	return &retryAlerter{alerter: alerter, policy: policy, deadLetter: deadLetter, sleep: time.Sleep}
+}
+
+// Wraps an alerter configured by environment variables with DefaultRetryPolicy, undeliverable alerts being
+// written to stderr. The env variable maxAttemptsEnv overrides the policy's number of attempts, 1 disabling retries.
+func withEnvRetryPolicy(alerter Alerter, maxAttemptsEnv string) (Alerter, error) {
+	policy := DefaultRetryPolicy
+	if env := os.Getenv(maxAttemptsEnv); env != "" {
+		attempts, err := strconv.Atoi(env)
+		if err != nil || attempts < 1 {
+			return nil, fmt.Errorf("Please set %s env var to a positive number of attempts", maxAttemptsEnv)
+		}
+		policy.MaxAttempts = attempts
+	}
+
+	return NewRetryAlerter(alerter, policy, os.Stderr), nil
+}
+
+func (r *retryAlerter) Alert(event *Event) error {
+	var err error
+	attempts := 0
+	for attempts < r.policy.MaxAttempts {
+		if attempts > 0 {
+			r.sleep(r.policy.backoff(attempts))
+		}
+		attempts++
+
+		if err = r.alerter.Alert(event); err == nil {
+			return nil
+		}
+		if _, ok := err.(*permanentAlertError); ok {
+			break
+		}
+	}
+
+	r.writeDeadLetter(event, err, attempts)
+
+	return fmt.Errorf("Unable to deliver alert for %s after %d attempt(s): %s", event.Check.Key, attempts, err)
+}
+
+func (r *retryAlerter) writeDeadLetter(event *Event, err error, attempts int) {
+	if r.deadLetter == nil {
+		return
+	}
+
+	data, jsonErr := json.Marshal(&deadLetter{Event: newJSONEvent(event), Error: err.Error(), Attempts: attempts, Time: time.Now()})
+	if jsonErr != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deadLetter.Write(append(data, '\n'))
+}
@@ -0,0 +1,90 @@
+package poller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+type failingTestAlerter struct {
+	failures int
+	err      error
+	attempts int
+}
+
+func (a *failingTestAlerter) Alert(event *Event) error {
+	a.attempts++
+	if a.attempts <= a.failures {
+		return a.err
+	}
+	return nil
+}
+
+func newTestRetryAlerter(alerter Alerter, deadLetter io.Writer) (*retryAlerter, *[]time.Duration) {
+	r := NewRetryAlerter(alerter, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: 3 * time.Second}, deadLetter).(*retryAlerter)
+	sleeps := make([]time.Duration, 0)
+	r.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+	}
+
+	return r, &sleeps
+}
+
+func TestRetryAlerter(t *testing.T) {
+	check, _ := NewCheck("foobar", "10s", false, "", false, make(map[string]interface{}))
+	deadLetter := new(bytes.Buffer)
+
+	alerter := &failingTestAlerter{failures: 2, err: errors.New("unavailable")}
+	r, sleeps := newTestRetryAlerter(alerter, deadLetter)
+	if err := r.Alert(NewEvent(check)); err != nil {
+		t.Errorf("Alert should have been delivered. Got %s", err)
+	}
+	if alerter.attempts != 3 {
+		t.Errorf("Alert should have been attempted 3 times. Got %d", alerter.attempts)
+	}
+	if len(*sleeps) != 2 || (*sleeps)[0] != time.Second || (*sleeps)[1] != 2*time.Second {
+		t.Errorf("Backoff is wrong. Got %v", *sleeps)
+	}
+	if deadLetter.Len() != 0 {
+		t.Error("Delivered alerts should not be written to the dead letter log")
+	}
+
+	alerter = &failingTestAlerter{failures: 5, err: errors.New("unavailable")}
+	r, _ = newTestRetryAlerter(alerter, deadLetter)
+	if err := r.Alert(NewEvent(check)); err == nil {
+		t.Error("Alert should have failed")
+	}
+	if alerter.attempts != 3 {
+		t.Errorf("Alert should have been attempted 3 times. Got %d", alerter.attempts)
+	}
+	var letter map[string]interface{}
+	if err := json.Unmarshal(deadLetter.Bytes(), &letter); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if letter["error"] != "unavailable" || letter["attempts"] != float64(3) || letter["event"].(map[string]interface{})["key"] != "foobar" {
+		t.Errorf("Dead letter is wrong. Got %v", letter)
+	}
+
+	alerter = &failingTestAlerter{failures: 5, err: permanentAlertErrorf("rejected")}
+	r, _ = newTestRetryAlerter(alerter, nil)
+	if err := r.Alert(NewEvent(check)); err == nil {
+		t.Error("Alert should have failed")
+	}
+	if alerter.attempts != 1 {
+		t.Errorf("Permanent errors should not be retried. Got %d attempts", alerter.attempts)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second, Jitter: 0.5}
+	for retry := 1; retry < 10; retry++ {
+		d := policy.backoff(retry)
+		if d < 500*time.Millisecond || d > 15*time.Second {
+			t.Errorf("Backoff of retry %d is out of bounds: %s", retry, d)
+		}
+	}
+}
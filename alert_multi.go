@@ -0,0 +1,135 @@
+package poller
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+)
+
+// An AlertRoute sends the alerts of matching checks to an Alerter.
+// A check matches if its key matches KeyPattern and if it has every tag of Tags with the same value.
+// KeyPattern uses the path.Match syntax (ie: "payments.*"), an empty pattern matches every key.
+// A Fallback route only receives the alerts no other route matched.
+// Checks and escalation steps referencing alerters by name bypass the matching, and are routed
+// to the routes with those Names.
+type AlertRoute struct {
+	Name       string
+	Alerter    Alerter
+	KeyPattern string
+	Tags       map[string]string
+	Fallback   bool
+}
+
+func (r *AlertRoute) matches(c *Check) bool {
+	if r.KeyPattern != "" {
+		if matched, _ := path.Match(r.KeyPattern, c.Key); !matched {
+			return false
+		}
+	}
+	for k, v := range r.Tags {
+		if value, ok := c.Tags[k]; !ok || value != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// A multiAlerter sends each alert to the alerters of every matching route
+type multiAlerter struct {
+	routes []AlertRoute
+	named  *namedAlerter // resolves the names referenced by checks and escalation steps to the named routes
+}
+
+// Instantiates an Alerter which dispatches alerts according to routes.
+// An error is returned if a route has no alerter or an invalid key pattern, or if two routes have the same name.
+func NewMultiAlerter(routes ...AlertRoute) (Alerter, error) {
+	named := &namedAlerter{alerters: make(map[string]Alerter)}
+	for i, route := range routes {
+		if route.Alerter == nil {
+			if route.Name != "" {
+				return nil, fmt.Errorf("Alert route %s has no alerter", route.Name)
+			}
+			return nil, fmt.Errorf("Alert route #%d has no alerter", i)
+		}
+		if _, err := path.Match(route.KeyPattern, ""); err != nil {
+			return nil, err
+		}
+		if route.Name == "" {
+			continue
+		}
+		if _, ok := named.alerters[route.Name]; ok {
+			return nil, fmt.Errorf("Alert route name %s is used more than once", route.Name)
+		}
+		named.alerters[route.Name] = route.Alerter
+	}
+
+	return &multiAlerter{routes: routes, named: named}, nil
+}
+
+// HasAlerter returns true if a route is named name
+func (m *multiAlerter) HasAlerter(name string) bool {
+	return m.named.HasAlerter(name)
+}
+
+// Returns the alerters which should receive the check's alerts
+func (m *multiAlerter) alerters(c *Check) []Alerter {
+	alerters := make([]Alerter, 0)
+	for i := range m.routes {
+		if !m.routes[i].Fallback && m.routes[i].matches(c) {
+			alerters = append(alerters, m.routes[i].Alerter)
+		}
+	}
+	if len(alerters) > 0 {
+		return alerters
+	}
+
+	for i := range m.routes {
+		if m.routes[i].Fallback && m.routes[i].matches(c) {
+			alerters = append(alerters, m.routes[i].Alerter)
+		}
+	}
+
+	return alerters
+}
+
+// Alert sends the event to every matching alerter concurrently and returns once all of them are done.
+// If some alerters failed, their errors are returned together.
+// Events referencing alerters by name are sent to the routes with those names instead.
+func (m *multiAlerter) Alert(event *Event) error {
+	if len(referencedAlerters(event)) > 0 {
+		return m.named.Alert(event)
+	}
+
+	return alertAll(m.alerters(event.Check), event)
+}
+
+// Sends the event to every alerter concurrently and returns once all of them are done.
+// If some alerters failed, their errors are returned together.
+func alertAll(alerters []Alerter, event *Event) error {
+	errs := make(chan error, len(alerters))
+
+	var wg sync.WaitGroup
+	for _, alerter := range alerters {
+		wg.Add(1)
+		go func(alerter Alerter) {
+			defer wg.Done()
+			if err := alerter.Alert(event); err != nil {
+				errs <- err
+			}
+		}(alerter)
+	}
+	wg.Wait()
+	close(errs)
+
+	messages := make([]string, 0)
+	for err := range errs {
+		messages = append(messages, err.Error())
+	}
+	if len(messages) > 0 {
+		return fmt.Errorf("%d alerter(s) failed: %s", len(messages), strings.Join(messages, "; "))
+	}
+
+	return nil
+}
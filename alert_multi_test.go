@@ -0,0 +1,90 @@
+package poller
+
+import (
+	"sync"
+	"testing"
+)
+
+type recordingTestAlerter struct {
+	keys []string
+	mu   sync.Mutex
+}
+
+func (a *recordingTestAlerter) Alert(event *Event) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.keys = append(a.keys, event.Check.Key)
+	return nil
+}
+
+func TestMultiAlerter(t *testing.T) {
+	pager := &recordingTestAlerter{}
+	team := &recordingTestAlerter{}
+	email := &recordingTestAlerter{}
+
+	alerter, err := NewMultiAlerter(
+		AlertRoute{Alerter: pager, KeyPattern: "payments.*"},
+		AlertRoute{Alerter: team, Tags: map[string]string{"team": "web"}},
+		AlertRoute{Alerter: email, Fallback: true})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	payments, _ := NewCheck("payments.api", "10s", false, "", false, make(map[string]interface{}))
+	payments.Tags = map[string]string{"team": "web"}
+	website, _ := NewCheck("website", "10s", false, "", false, make(map[string]interface{}))
+	website.Tags = map[string]string{"team": "marketing"}
+
+	alerter.Alert(NewEvent(payments))
+	alerter.Alert(NewEvent(website))
+
+	if len(pager.keys) != 1 || pager.keys[0] != "payments.api" {
+		t.Errorf("Pager should have received payments.api only. Got %v", pager.keys)
+	}
+	if len(team.keys) != 1 || team.keys[0] != "payments.api" {
+		t.Errorf("Team should have received payments.api only. Got %v", team.keys)
+	}
+	if len(email.keys) != 1 || email.keys[0] != "website" {
+		t.Errorf("Email should have received website only. Got %v", email.keys)
+	}
+
+	if _, err := NewMultiAlerter(AlertRoute{Alerter: email, KeyPattern: "["}); err == nil {
+		t.Error("An invalid key pattern should return an error")
+	}
+}
+
+func TestMultiAlerterNamedRoutes(t *testing.T) {
+	chat := &recordingTestAlerter{}
+	pager := &recordingTestAlerter{}
+
+	alerter, _ := NewMultiAlerter(
+		AlertRoute{Name: "chat", Alerter: chat},
+		AlertRoute{Name: "pager", Alerter: pager, KeyPattern: "payments.*"})
+	if !alerter.(AlerterRouter).HasAlerter("pager") || alerter.(AlerterRouter).HasAlerter("email") {
+		t.Error("HasAlerter() should only know the named routes")
+	}
+
+	// Escalation steps only reach their own alerters, even if other routes match
+	payments, _ := NewCheck("payments.api", "10s", false, "", false, make(map[string]interface{}))
+	event := NewEvent(payments)
+	event.Alerters = []string{"chat"}
+	if err := alerter.Alert(event); err != nil {
+		t.Error(err)
+	}
+	if len(chat.keys) != 1 || len(pager.keys) != 0 {
+		t.Errorf("Only chat should have been alerted. Got %v and %v", chat.keys, pager.keys)
+	}
+
+	event.Alerters = []string{"email"}
+	if err := alerter.Alert(event); err == nil {
+		t.Error("Unknown alerters should be reported")
+	}
+
+	if _, err := NewMultiAlerter(AlertRoute{Name: "chat", Alerter: chat}, AlertRoute{Name: "chat", Alerter: pager}); err == nil {
+		t.Error("Routes with the same name should return an error")
+	}
+	if _, err := NewMultiAlerter(AlertRoute{Alerter: chat}, AlertRoute{Fallback: true}); err == nil || err.Error() != "Alert route #1 has no alerter" {
+		t.Errorf("Routes without alerter should be reported by index. Got %v", err)
+	}
+}
@@ -0,0 +1,429 @@
+package poller
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const (
+	defaultSmtpSubjectTemplate = `{{if eq (len .Alerts) 1}}{{if .Event.IsUp}}[RECOVERY] {{.Check.Key}} is up{{else}}[ALERT] {{.Check.Key}} is down{{end}}{{else}}[ALERT] {{len .Alerts}} checks changed state{{end}}`
+	defaultSmtpTextTemplate    = `{{range .Alerts}}{{if .Event.IsUp}}Poller recovery: {{.Check.AlertDescription}} is up again after {{.Check.WasDownFor}}{{else}}Poller alert: {{.Check.AlertDescription}} is down since {{.Check.DownSince.Format "02 Jan 06 15:04 MST"}}{{end}}
+{{end}}`
+)
+
+// SMTP alerter's options
+type SmtpOptions struct {
+	Host     string
+	Port     string
+	Auth     string // Either empty, "MD5" or "PLAIN"
+	Username string
+	Password string
+	Identity string // Identity used by PLAIN auth
+	From     string
+	To       []string
+
+	// Either "starttls" (STARTTLS is required), "tls" (implicit TLS), "none" (no encryption), or empty
+	// (STARTTLS is used if the server supports it)
+	TLS    string
+	CAFile string // PEM encoded certificates used to verify the server, instead of the system's
+
+	// Templates have access to .Event and .Check, the first alert of the email, and to .Alerts which lists
+	// every alert of the email as .Event and .Check pairs. When HTMLTemplate is set, the email is sent as
+	// a multipart message holding both the text and the HTML versions.
+	SubjectTemplate string
+	TextTemplate    string
+	HTMLTemplate    string
+
+	// Alerts raised within DigestDelay of each other are sent in a single email. Zero disables digests.
+	// Failed digests are retried according to DigestRetryPolicy (zero value = a single attempt). Their error
+	// is permanent, so that retrying the alerts one by one does not send the digest again in fragments.
+	DigestDelay       time.Duration
+	DigestRetryPolicy RetryPolicy
+}
+
+// Data email templates are rendered with
+type smtpTemplateData struct {
+	Event  *Event
+	Check  *Check
+	Alerts []*alertTemplateData
+}
+
+// Alerts waiting to be sent in the same email
+type smtpBatch struct {
+	events []*Event
+	err    error
+	done   chan int
+}
+
+// SMTP
+type smtpAlerter struct {
+	options   SmtpOptions
+	addr      string
+	auth      smtp.Auth
+	tlsConfig *tls.Config
+	subject   *template.Template
+	text      *template.Template
+	html      *htmltemplate.Template
+	batch     *smtpBatch
+	mu        sync.Mutex
+	sleep     func(time.Duration)
+}
+
+// Instantiates an Alerter sending emails, configured by environment variables.
+// See the README for the list of variables. Failed deliveries are retried according to DefaultRetryPolicy.
+func NewSmtpAlerter() (Alerter, error) {
+	options := SmtpOptions{
+		Host:            os.Getenv("SMTP_HOST"),
+		Port:            os.Getenv("SMTP_PORT"),
+		Auth:            os.Getenv("SMTP_AUTH"),
+		Username:        os.Getenv("SMTP_USERNAME"),
+		Password:        os.Getenv("SMTP_PASSWORD"),
+		Identity:        os.Getenv("SMTP_PLAIN_IDENTITY"),
+		From:            os.Getenv("SMTP_FROM"),
+		TLS:             os.Getenv("SMTP_TLS"),
+		CAFile:          os.Getenv("SMTP_CA_FILE"),
+		SubjectTemplate: os.Getenv("SMTP_SUBJECT_TEMPLATE")}
+
+	if options.Host == "" {
+		return nil, fmt.Errorf("Please define SMTP_HOST env var")
+	}
+	if options.Port == "" {
+		return nil, fmt.Errorf("Please define SMTP_PORT env var")
+	}
+
+	if envTo := os.Getenv("SMTP_RECIPIENT"); envTo != "" {
+		options.To = strings.Split(envTo, ";")
+	}
+
+	if path := os.Getenv("SMTP_TEXT_TEMPLATE_FILE"); path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		options.TextTemplate = string(data)
+	}
+	if path := os.Getenv("SMTP_HTML_TEMPLATE_FILE"); path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		options.HTMLTemplate = string(data)
+	}
+
+	if envDelay := os.Getenv("SMTP_DIGEST_DELAY"); envDelay != "" {
+		delay, err := time.ParseDuration(envDelay)
+		if err != nil {
+			return nil, fmt.Errorf("Please set SMTP_DIGEST_DELAY env var to a valid duration: %s", err)
+		}
+		options.DigestDelay = delay
+	}
+
+	alerter, err := NewSmtpAlerterWithOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return withEnvRetryPolicy(alerter, "SMTP_MAX_ATTEMPTS")
+}
+
+// Instantiates an Alerter sending emails according to options.
+// Down checks are alerted, and recoveries are notified.
+func NewSmtpAlerterWithOptions(options SmtpOptions) (Alerter, error) {
+	if options.Host == "" {
+		return nil, fmt.Errorf("SMTP host cannot be empty")
+	}
+	if options.Port == "" {
+		options.Port = "25"
+	}
+	if options.From == "" {
+		return nil, fmt.Errorf("SMTP sender cannot be empty")
+	}
+	if len(options.To) == 0 {
+		return nil, fmt.Errorf("SMTP recipients cannot be empty")
+	}
+
+	m := &smtpAlerter{options: options, addr: net.JoinHostPort(options.Host, options.Port), sleep: time.Sleep}
+
+	switch options.Auth {
+	case "":
+	case "MD5":
+		m.auth = smtp.CRAMMD5Auth(options.Username, options.Password)
+	case "PLAIN":
+		m.auth = smtp.PlainAuth(options.Identity, options.Username, options.Password, options.Host)
+	default:
+		return nil, fmt.Errorf("SMTP auth should either be empty, MD5 or PLAIN")
+	}
+
+	switch options.TLS {
+	case "", "starttls", "tls", "none":
+	default:
+		return nil, fmt.Errorf("SMTP TLS should either be empty, starttls, tls or none")
+	}
+
+	m.tlsConfig = &tls.Config{ServerName: options.Host}
+	if options.CAFile != "" {
+		pool, err := loadCertPool(options.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		m.tlsConfig.RootCAs = pool
+	}
+
+	if options.SubjectTemplate == "" {
+		options.SubjectTemplate = defaultSmtpSubjectTemplate
+	}
+	if options.TextTemplate == "" {
+		options.TextTemplate = defaultSmtpTextTemplate
+	}
+
+	var err error
+	if m.subject, err = template.New("subject").Parse(options.SubjectTemplate); err != nil {
+		return nil, err
+	}
+	if m.text, err = template.New("text").Parse(options.TextTemplate); err != nil {
+		return nil, err
+	}
+	if options.HTMLTemplate != "" {
+		if m.html, err = htmltemplate.New("html").Parse(options.HTMLTemplate); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// Alert sends the event by email. When digests are enabled, it waits for the digest to be sent.
+func (m *smtpAlerter) Alert(event *Event) error {
+	if m.options.DigestDelay <= 0 {
+		return m.send([]*Event{event})
+	}
+
+	m.mu.Lock()
+	if m.batch == nil {
+		m.batch = &smtpBatch{done: make(chan int)}
+		time.AfterFunc(m.options.DigestDelay, m.flush)
+	}
+	batch := m.batch
+	batch.events = append(batch.events, event)
+	m.mu.Unlock()
+
+	<-batch.done
+	return batch.err
+}
+
+// Sends the current digest
+func (m *smtpAlerter) flush() {
+	m.mu.Lock()
+	batch := m.batch
+	m.batch = nil
+	m.mu.Unlock()
+
+	batch.err = m.sendDigest(batch.events)
+	close(batch.done)
+}
+
+// Sends a digest, retrying according to the DigestRetryPolicy. The digest is attempted once for all
+// of its alerts, the returned error is therefore permanent.
+func (m *smtpAlerter) sendDigest(events []*Event) error {
+	policy := m.options.DigestRetryPolicy
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = m.send(events); err == nil {
+			return nil
+		}
+		if _, ok := err.(*permanentAlertError); ok {
+			return err
+		}
+		if attempt >= policy.MaxAttempts {
+			return permanentAlertErrorf("Unable to send digest of %d alert(s) after %d attempt(s): %s", len(events), attempt, err)
+		}
+		m.sleep(policy.backoff(attempt))
+	}
+}
+
+// Renders the email holding the events
+func (m *smtpAlerter) message(events []*Event) ([]byte, error) {
+	data := &smtpTemplateData{Event: events[0], Check: events[0].Check}
+	for _, e := range events {
+		data.Alerts = append(data.Alerts, &alertTemplateData{Event: e, Check: e.Check})
+	}
+
+	subject := new(bytes.Buffer)
+	if err := m.subject.Execute(subject, data); err != nil {
+		return nil, permanentAlertErrorf("Unable to render email subject: %s", err)
+	}
+	text := new(bytes.Buffer)
+	if err := m.text.Execute(text, data); err != nil {
+		return nil, permanentAlertErrorf("Unable to render email body: %s", err)
+	}
+
+	to := make([]string, len(m.options.To))
+	for i, v := range m.options.To {
+		to[i] = (&mail.Address{Address: v}).String()
+	}
+
+	message := new(bytes.Buffer)
+	fmt.Fprintf(message, "From: %s\r\n", (&mail.Address{Address: m.options.From}).String())
+	fmt.Fprintf(message, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject.String())))
+	fmt.Fprintf(message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(message, "MIME-Version: 1.0\r\n")
+
+	if m.html == nil {
+		fmt.Fprintf(message, "Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(message, text.Bytes()); err != nil {
+			return nil, err
+		}
+		return message.Bytes(), nil
+	}
+
+	html := new(bytes.Buffer)
+	if err := m.html.Execute(html, data); err != nil {
+		return nil, permanentAlertErrorf("Unable to render email HTML body: %s", err)
+	}
+
+	parts := multipart.NewWriter(message)
+	fmt.Fprintf(message, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+	for _, part := range []struct {
+		contentType string
+		body        []byte
+	}{{"text/plain; charset=utf-8", text.Bytes()}, {"text/html; charset=utf-8", html.Bytes()}} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"}})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	return message.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, body []byte) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write(body); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// Connects to the SMTP server, according to the TLS option
+func (m *smtpAlerter) dial() (*smtp.Client, error) {
+	if m.options.TLS == "tls" {
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", m.addr, m.tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		c, err := smtp.NewClient(conn, m.options.Host)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return c, nil
+	}
+
+	conn, err := net.DialTimeout("tcp", m.addr, 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	c, err := smtp.NewClient(conn, m.options.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if m.options.TLS == "none" {
+		return c, nil
+	}
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(m.tlsConfig); err != nil {
+			c.Close()
+			return nil, err
+		}
+	} else if m.options.TLS == "starttls" {
+		c.Close()
+		return nil, permanentAlertErrorf("SMTP server %s does not support STARTTLS", m.addr)
+	}
+
+	return c, nil
+}
+
+func (m *smtpAlerter) send(events []*Event) error {
+	message, err := m.message(events)
+	if err != nil {
+		return err
+	}
+
+	c, err := m.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := m.transmit(c, message); err != nil {
+		// Permanent negative completion replies will not be fixed by retrying
+		if e, ok := err.(*textproto.Error); ok && e.Code >= 500 {
+			return permanentAlertErrorf("SMTP server rejected the alert: %s", e)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (m *smtpAlerter) transmit(c *smtp.Client, message []byte) error {
+	if m.auth != nil {
+		if err := c.Auth(m.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(m.options.From); err != nil {
+		return err
+	}
+	for _, to := range m.options.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	// The message is accepted once DATA is done, failing to QUIT afterwards does not mean it was not delivered
+	if err := c.Quit(); err != nil {
+		log.Printf("Unable to QUIT SMTP server %s after the alert was accepted: %s", m.addr, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,393 @@
+package poller
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/pem"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http/httptest"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Minimal SMTP server. Received messages are sent to messages.
+type testSmtpServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config // STARTTLS is advertised when set
+	rejectTo  string
+	failData  int  // number of messages rejected with a temporary error before accepting them
+	dropQuit  bool // close the connection instead of answering QUIT
+	messages  chan string
+	mu        sync.Mutex
+}
+
+func newTestSmtpServer(t *testing.T, tlsConfig *tls.Config, implicitTLS bool) *testSmtpServer {
+	var l net.Listener
+	var err error
+	if implicitTLS {
+		l, err = tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	} else {
+		l, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	s := &testSmtpServer{listener: l, messages: make(chan string, 10)}
+	if !implicitTLS {
+		s.tlsConfig = tlsConfig
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *testSmtpServer) Port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return port
+}
+
+func (s *testSmtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSpace(line)
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch {
+		case verb == "EHLO" && s.tlsConfig != nil:
+			reply("250-localhost")
+			reply("250 STARTTLS")
+		case verb == "EHLO" || verb == "HELO":
+			reply("250 localhost")
+		case verb == "STARTTLS":
+			reply("220 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, r = tlsConn, bufio.NewReader(tlsConn)
+			reply = func(line string) { tlsConn.Write([]byte(line + "\r\n")) }
+		case verb == "RCPT" && s.rejectTo != "" && strings.Contains(line, s.rejectTo):
+			reply("550 No such user")
+		case verb == "DATA":
+			reply("354 Go ahead")
+			data := new(strings.Builder)
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.mu.Lock()
+			fail := s.failData > 0
+			s.failData--
+			s.mu.Unlock()
+			if fail {
+				reply("451 Try again later")
+				continue
+			}
+			s.messages <- data.String()
+			reply("250 OK")
+		case verb == "QUIT" && s.dropQuit:
+			return
+		case verb == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func newTestSmtpEvent(key string, up bool) *Event {
+	check, _ := NewCheck(key, "10s", false, "", false, make(map[string]interface{}))
+	event := NewEvent(check)
+	if up {
+		event.Up()
+	} else {
+		event.Down()
+	}
+
+	return event
+}
+
+func TestSmtpAlerter(t *testing.T) {
+	server := newTestSmtpServer(t, nil, false)
+	defer server.listener.Close()
+
+	alerter, err := NewSmtpAlerterWithOptions(SmtpOptions{Host: "127.0.0.1", Port: server.Port(), From: "poller@example.org", To: []string{"ops@example.org"}})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	if err := alerter.Alert(newTestSmtpEvent("foobar", false)); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(<-server.messages))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if msg.Header.Get("Subject") != "[ALERT] foobar is down" {
+		t.Errorf("Subject is wrong. Got %s", msg.Header.Get("Subject"))
+	}
+	body, _ := ioutil.ReadAll(msg.Body)
+	if !strings.HasPrefix(string(body), "Poller alert: ") || !strings.Contains(string(body), " is down since ") {
+		t.Errorf("Body is wrong. Got %s", body)
+	}
+
+	// Recoveries are notified too
+	alerter.Alert(newTestSmtpEvent("foobar", true))
+	msg, _ = mail.ReadMessage(strings.NewReader(<-server.messages))
+	if msg.Header.Get("Subject") != "[RECOVERY] foobar is up" {
+		t.Errorf("Subject is wrong. Got %s", msg.Header.Get("Subject"))
+	}
+}
+
+func TestSmtpAlerterTemplates(t *testing.T) {
+	server := newTestSmtpServer(t, nil, false)
+	defer server.listener.Close()
+
+	alerter, _ := NewSmtpAlerterWithOptions(SmtpOptions{
+		Host:            "127.0.0.1",
+		Port:            server.Port(),
+		From:            "poller@example.org",
+		To:              []string{"ops@example.org"},
+		SubjectTemplate: "{{.Check.Key}} changed",
+		TextTemplate:    "{{.Check.Key}} is down",
+		HTMLTemplate:    "<b>{{.Check.Key}}</b> is down"})
+
+	alerter.Alert(newTestSmtpEvent("foo<bar>", false))
+	msg, _ := mail.ReadMessage(strings.NewReader(<-server.messages))
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "foo<bar> changed" {
+		t.Errorf("Subject is wrong. Got %s", subject)
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if mediaType != "multipart/alternative" {
+		t.Logf("Message should be multipart/alternative. Got %s", mediaType)
+		t.FailNow()
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := parts.NextPart()
+		if err != nil {
+			break
+		}
+		body, _ := ioutil.ReadAll(part)
+		bodies = append(bodies, string(body))
+	}
+	if len(bodies) != 2 || bodies[0] != "foo<bar> is down" || bodies[1] != "<b>foo&lt;bar&gt;</b> is down" {
+		t.Errorf("Parts are wrong. Got %q", bodies)
+	}
+}
+
+func TestSmtpAlerterDigest(t *testing.T) {
+	server := newTestSmtpServer(t, nil, false)
+	defer server.listener.Close()
+
+	alerter, _ := NewSmtpAlerterWithOptions(SmtpOptions{
+		Host:        "127.0.0.1",
+		Port:        server.Port(),
+		From:        "poller@example.org",
+		To:          []string{"ops@example.org"},
+		DigestDelay: 50 * time.Millisecond})
+
+	errs := make(chan error, 2)
+	go func() { errs <- alerter.Alert(newTestSmtpEvent("foo", false)) }()
+	go func() { errs <- alerter.Alert(newTestSmtpEvent("bar", true)) }()
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+
+	msg, _ := mail.ReadMessage(strings.NewReader(<-server.messages))
+	if msg.Header.Get("Subject") != "[ALERT] 2 checks changed state" {
+		t.Errorf("Subject is wrong. Got %s", msg.Header.Get("Subject"))
+	}
+	body, _ := ioutil.ReadAll(msg.Body)
+	if !strings.Contains(string(body), "is down since") || !strings.Contains(string(body), "is up again") {
+		t.Errorf("Body should list both alerts. Got %s", body)
+	}
+	select {
+	case <-server.messages:
+		t.Error("Alerts should be sent in a single email")
+	default:
+	}
+}
+
+func TestSmtpAlerterDigestRetry(t *testing.T) {
+	server := newTestSmtpServer(t, nil, false)
+	server.failData = 2
+	defer server.listener.Close()
+
+	alerter, _ := NewSmtpAlerterWithOptions(SmtpOptions{
+		Host:              "127.0.0.1",
+		Port:              server.Port(),
+		From:              "poller@example.org",
+		To:                []string{"ops@example.org"},
+		DigestDelay:       50 * time.Millisecond,
+		DigestRetryPolicy: RetryPolicy{MaxAttempts: 3}})
+	alerter.(*smtpAlerter).sleep = func(time.Duration) {}
+
+	errs := make(chan error, 2)
+	go func() { errs <- alerter.Alert(newTestSmtpEvent("foo", false)) }()
+	go func() { errs <- alerter.Alert(newTestSmtpEvent("bar", false)) }()
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+	<-server.messages
+	select {
+	case <-server.messages:
+		t.Error("The digest should be sent once")
+	default:
+	}
+
+	// Once every attempt failed, the error is permanent so that alerts are not retried one by one
+	server.failData = 3
+	go func() { errs <- alerter.Alert(newTestSmtpEvent("foo", false)) }()
+	go func() { errs <- alerter.Alert(newTestSmtpEvent("bar", false)) }()
+	for i := 0; i < 2; i++ {
+		if _, ok := (<-errs).(*permanentAlertError); !ok {
+			t.Error("A failed digest should return a permanent error")
+		}
+	}
+}
+
+func TestSmtpAlerterQuitFailure(t *testing.T) {
+	server := newTestSmtpServer(t, nil, false)
+	server.dropQuit = true
+	defer server.listener.Close()
+
+	alerter, _ := NewSmtpAlerterWithOptions(SmtpOptions{Host: "127.0.0.1", Port: server.Port(), From: "poller@example.org", To: []string{"ops@example.org"}})
+	if err := alerter.Alert(newTestSmtpEvent("foobar", false)); err != nil {
+		t.Errorf("The alert was accepted before QUIT. Got %s", err)
+	}
+	<-server.messages
+}
+
+func TestSmtpAlerterTLS(t *testing.T) {
+	// Borrow the test server's self-signed certificate
+	https := httptest.NewTLSServer(successTestHandler{})
+	tlsConfig := &tls.Config{Certificates: https.TLS.Certificates}
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: https.Certificate().Raw}), 0644)
+	https.Close()
+
+	for _, mode := range []string{"starttls", "tls"} {
+		server := newTestSmtpServer(t, tlsConfig, mode == "tls")
+		options := SmtpOptions{Host: "127.0.0.1", Port: server.Port(), From: "poller@example.org", To: []string{"ops@example.org"}, TLS: mode}
+
+		// The certificate is not trusted by the system's pool
+		alerter, _ := NewSmtpAlerterWithOptions(options)
+		if err := alerter.Alert(newTestSmtpEvent("foobar", false)); err == nil {
+			t.Errorf("%s: Alert should fail when the certificate is not trusted", mode)
+		}
+
+		options.CAFile = caFile
+		alerter, _ = NewSmtpAlerterWithOptions(options)
+		if err := alerter.Alert(newTestSmtpEvent("foobar", false)); err != nil {
+			t.Errorf("%s: %s", mode, err)
+		} else {
+			<-server.messages
+		}
+		server.listener.Close()
+	}
+
+	// STARTTLS is required but not supported
+	server := newTestSmtpServer(t, nil, false)
+	defer server.listener.Close()
+	alerter, _ := NewSmtpAlerterWithOptions(SmtpOptions{Host: "127.0.0.1", Port: server.Port(), From: "poller@example.org", To: []string{"ops@example.org"}, TLS: "starttls"})
+	if _, ok := alerter.Alert(newTestSmtpEvent("foobar", false)).(*permanentAlertError); !ok {
+		t.Error("Alert should fail permanently")
+	}
+}
+
+func TestSmtpAlerterRejectedRecipient(t *testing.T) {
+	server := newTestSmtpServer(t, nil, false)
+	server.rejectTo = "nobody@example.org"
+	defer server.listener.Close()
+
+	alerter, _ := NewSmtpAlerterWithOptions(SmtpOptions{Host: "127.0.0.1", Port: server.Port(), From: "poller@example.org", To: []string{"nobody@example.org"}})
+	if err, ok := alerter.Alert(newTestSmtpEvent("foobar", false)).(*permanentAlertError); !ok {
+		t.Errorf("Alert should fail permanently. Got %v", err)
+	}
+}
+
+func TestNewSmtpAlerter(t *testing.T) {
+	os.Setenv("SMTP_HOST", "localhost")
+	os.Setenv("SMTP_PORT", "25")
+	os.Setenv("SMTP_FROM", "poller@example.org")
+	os.Setenv("SMTP_RECIPIENT", "ops@example.org;dev@example.org")
+	defer os.Unsetenv("SMTP_HOST")
+	defer os.Unsetenv("SMTP_PORT")
+	defer os.Unsetenv("SMTP_FROM")
+	defer os.Unsetenv("SMTP_RECIPIENT")
+
+	alerter, err := NewSmtpAlerter()
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	retry, ok := alerter.(*retryAlerter)
+	if !ok || retry.policy.MaxAttempts != DefaultRetryPolicy.MaxAttempts {
+		t.Log("Deliveries should be retried according to DefaultRetryPolicy")
+		t.FailNow()
+	}
+	if to := retry.alerter.(*smtpAlerter).options.To; len(to) != 2 {
+		t.Errorf("Recipients are wrong. Got %v", to)
+	}
+
+	os.Setenv("SMTP_MAX_ATTEMPTS", "1")
+	defer os.Unsetenv("SMTP_MAX_ATTEMPTS")
+	if alerter, err = NewSmtpAlerter(); err != nil || alerter.(*retryAlerter).policy.MaxAttempts != 1 {
+		t.Errorf("SMTP_MAX_ATTEMPTS should set the number of attempts. Got %v", err)
+	}
+	os.Setenv("SMTP_MAX_ATTEMPTS", "0")
+	if _, err := NewSmtpAlerter(); err == nil {
+		t.Error("An invalid SMTP_MAX_ATTEMPTS should be rejected")
+	}
+	os.Unsetenv("SMTP_MAX_ATTEMPTS")
+
+	os.Setenv("SMTP_DIGEST_DELAY", "foo")
+	defer os.Unsetenv("SMTP_DIGEST_DELAY")
+	if _, err := NewSmtpAlerter(); err == nil {
+		t.Error("An invalid SMTP_DIGEST_DELAY should be rejected")
+	}
+}
@@ -0,0 +1,65 @@
+package poller
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestPagerDutyAlerter(t *testing.T) {
+	received := make(chan map[string]interface{}, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var e map[string]interface{}
+		json.Unmarshal(body, &e)
+		received <- e
+		w.WriteHeader(202)
+	}))
+	defer server.Close()
+
+	os.Setenv("PAGERDUTY_ROUTING_KEY", "routing_key")
+	os.Setenv("PAGERDUTY_ENDPOINT", server.URL)
+	os.Setenv("PAGERDUTY_SOURCE", "poller.example.org")
+	defer os.Unsetenv("PAGERDUTY_ROUTING_KEY")
+	defer os.Unsetenv("PAGERDUTY_ENDPOINT")
+	defer os.Unsetenv("PAGERDUTY_SOURCE")
+
+	alerter, err := NewPagerDutyAlerter()
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if _, ok := alerter.(*retryAlerter); !ok {
+		t.Error("Deliveries should be retried by default")
+	}
+
+	check, _ := NewCheckFromJSON([]byte(testJsonHttpCheck))
+	event := NewEvent(check)
+	event.StatusCode = 500
+	event.Down()
+	alerter.Alert(event)
+
+	e := <-received
+	if e["routing_key"] != "routing_key" || e["event_action"] != "trigger" || e["dedup_key"] != "connect_sensiolabs_com_api" {
+		t.Errorf("Trigger event is wrong. Got %v", e)
+	}
+	payload := e["payload"].(map[string]interface{})
+	if payload["severity"] != "critical" || payload["source"] != "poller.example.org" || payload["component"] != "connect_sensiolabs_com_api" {
+		t.Errorf("Trigger payload is wrong. Got %v", payload)
+	}
+	if payload["custom_details"].(map[string]interface{})["status_code"] != float64(500) {
+		t.Errorf("Custom details are wrong. Got %v", payload["custom_details"])
+	}
+
+	event = NewEvent(check)
+	event.Up()
+	alerter.Alert(event)
+
+	e = <-received
+	if e["event_action"] != "resolve" || e["dedup_key"] != "connect_sensiolabs_com_api" {
+		t.Errorf("Resolve event is wrong. Got %v", e)
+	}
+}
@@ -0,0 +1,99 @@
+package poller
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+// Template used when the webhook alerter is not given one
+const defaultWebhookTemplate = `{"key": {{json .Check.Key}}, "description": {{json .Check.AlertDescription}}, "up": {{.Event.IsUp}}, "downSince": {{json .Check.DownSince}}, "statusCode": {{.Event.StatusCode}}}`
+
+// Header holding the HMAC-SHA256 signature of the payload
+const webhookSignatureHeader = "X-Poller-Signature"
+
+// Data alert templates are rendered with
+type alertTemplateData struct {
+	Event *Event
+	Check *Check
+}
+
+var webhookTemplateFuncs = template.FuncMap{
+	// Encodes a value in JSON, ie: to safely embed strings in a JSON payload
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// Webhook
+type webhookAlerter struct {
+	url      string
+	template *template.Template
+	headers  map[string]string
+	secret   []byte
+	client   *http.Client
+}
+
+// Instantiates an Alerter which POSTs alerts to url.
+// The payload is rendered from bodyTemplate, a text/template which has access to .Event and .Check,
+// and to a json function which encodes its argument in JSON. A default JSON payload is used if bodyTemplate is empty.
+// headers are added to the request, which Content-Type defaults to application/json.
+// If secret is not empty, the hex encoded HMAC-SHA256 of the payload is sent in the X-Poller-Signature header,
+// as "sha256=<signature>".
+func NewWebhookAlerter(url, bodyTemplate string, headers map[string]string, secret string) (Alerter, error) {
+	if url == "" {
+		return nil, fmt.Errorf("Webhook URL cannot be empty")
+	}
+
+	if bodyTemplate == "" {
+		bodyTemplate = defaultWebhookTemplate
+	}
+
+	tmpl, err := template.New("webhook").Funcs(webhookTemplateFuncs).Parse(bodyTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhookAlerter{
+		url:      url,
+		template: tmpl,
+		headers:  headers,
+		secret:   []byte(secret),
+		client:   &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+func (w *webhookAlerter) Alert(event *Event) error {
+	body := new(bytes.Buffer)
+	if err := w.template.Execute(body, &alertTemplateData{Event: event, Check: event.Check}); err != nil {
+		return permanentAlertErrorf("Unable to render webhook payload: %s", err)
+	}
+
+	req, err := http.NewRequest("POST", w.url, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return permanentAlertErrorf("Unable to send webhook: %s", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+	if len(w.secret) > 0 {
+		mac := hmac.New(sha256.New, w.secret)
+		mac.Write(body.Bytes())
+		req.Header.Set(webhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return httpAlertError("Webhook", resp.StatusCode)
+}
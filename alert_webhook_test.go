@@ -0,0 +1,66 @@
+package poller
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookAlerter(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	alerter, err := NewWebhookAlerter(server.URL, `{"text": {{json (printf "%s is down (%d)" .Check.Key .Event.StatusCode)}}}`, map[string]string{"X-Team": "web"}, "secret")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	check, _ := NewCheckFromJSON([]byte(testJsonHttpCheck))
+	event := NewEvent(check)
+	event.StatusCode = 500
+	event.Down()
+	alerter.Alert(event)
+
+	r := <-received
+	body := <-bodies
+	if string(body) != `{"text": "connect_sensiolabs_com_api is down (500)"}` {
+		t.Errorf("Body is wrong. Got %s", body)
+	}
+	if r.Header.Get("X-Team") != "web" || r.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Headers are wrong. Got %v", r.Header)
+	}
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	if r.Header.Get("X-Poller-Signature") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("Signature is wrong. Got %s", r.Header.Get("X-Poller-Signature"))
+	}
+
+	// The default template renders a JSON payload
+	alerter, _ = NewWebhookAlerter(server.URL, "", nil, "")
+	alerter.Alert(event)
+	<-received
+	var payload map[string]interface{}
+	if err := json.Unmarshal(<-bodies, &payload); err != nil {
+		t.Errorf("Default payload should be valid JSON: %s", err)
+	}
+	if payload["key"] != "connect_sensiolabs_com_api" || payload["up"] != false {
+		t.Errorf("Default payload is wrong. Got %v", payload)
+	}
+
+	if _, err := NewWebhookAlerter(server.URL, "{{", nil, ""); err == nil {
+		t.Error("An invalid template should return an error")
+	}
+}
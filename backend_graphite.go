@@ -0,0 +1,154 @@
+package poller
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// Maximum number of bytes kept in memory while Graphite is unreachable
+const graphiteMaxBufferSize = 1 << 20
+
+// Backend for Graphite's plaintext protocol
+type graphiteBackend struct {
+	addr   string
+	prefix string
+	conn   net.Conn
+	lines  *bytes.Buffer
+	mu     sync.Mutex
+	stop   chan int
+	done   chan int
+	once   sync.Once
+}
+
+// Instanciate a new Backend that will send data to Carbon in the plaintext protocol, over TCP.
+// Metrics are sent the same way as the Librato backend: the duration in milliseconds to
+// <prefix><key>.duration and 1 or 0 to <prefix><key>.up. prefix defaults to "checks." and port to 2003.
+// Metrics are batched and flushed every flushInterval, which defaults to 10s. If the connection is lost,
+// it is reestablished on the next flush and the pending metrics are sent again.
+func NewGraphiteBackend(host, port, prefix string, flushInterval time.Duration) (Backend, error) {
+	if host == "" {
+		return nil, fmt.Errorf("Graphite host cannot be empty")
+	}
+
+	if port == "" {
+		port = "2003"
+	}
+
+	if prefix == "" {
+		prefix = "checks."
+	}
+
+	if flushInterval <= 0 {
+		flushInterval = 10 * time.Second
+	}
+
+	g := &graphiteBackend{
+		addr:   net.JoinHostPort(host, port),
+		prefix: prefix,
+		lines:  new(bytes.Buffer),
+		stop:   make(chan int),
+		done:   make(chan int)}
+
+	if err := g.connect(); err != nil {
+		return nil, err
+	}
+
+	go g.flushEvery(flushInterval)
+
+	return g, nil
+}
+
+func (g *graphiteBackend) connect() error {
+	conn, err := net.DialTimeout("tcp", g.addr, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	g.conn = conn
+
+	return nil
+}
+
+func (g *graphiteBackend) flushEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer close(g.done)
+
+	for {
+		select {
+		case <-ticker.C:
+			g.flush()
+		case <-g.stop:
+			g.flush()
+			if g.conn != nil {
+				g.conn.Close()
+			}
+			return
+		}
+	}
+}
+
+func (g *graphiteBackend) flush() {
+	g.mu.Lock()
+	if g.lines.Len() == 0 {
+		g.mu.Unlock()
+		return
+	}
+	data := g.lines.Bytes()
+	g.lines = new(bytes.Buffer)
+	g.mu.Unlock()
+
+	err := g.write(data)
+	if err == nil {
+		return
+	}
+	log.Println("Unable to send metrics to Graphite:", err)
+
+	// Keep the metrics for the next flush, unless too many are pending already
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(data)+g.lines.Len() > graphiteMaxBufferSize {
+		log.Printf("Graphite buffer is full, dropping %d bytes of metrics", len(data))
+		return
+	}
+	pending := g.lines.Bytes()
+	g.lines = bytes.NewBuffer(append(data, pending...))
+}
+
+func (g *graphiteBackend) write(data []byte) error {
+	if g.conn == nil {
+		if err := g.connect(); err != nil {
+			return err
+		}
+	}
+
+	g.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := g.conn.Write(data); err != nil {
+		g.conn.Close()
+		g.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+func (g *graphiteBackend) Log(e *Event) {
+	timestamp := e.Time.Unix()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	fmt.Fprintf(g.lines, "%s%s.duration %d %d\n", g.prefix, e.Check.Key, e.Duration.Nanoseconds()/int64(time.Millisecond), timestamp)
+	fmt.Fprintf(g.lines, "%s%s.up %d %d\n", g.prefix, e.Check.Key, btou(e.IsUp()), timestamp)
+}
+
+// Close flushes the pending metrics and closes the connection. It can be called more than once.
+func (g *graphiteBackend) Close() {
+	g.once.Do(func() {
+		close(g.stop)
+	})
+	<-g.done
+}
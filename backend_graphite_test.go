@@ -0,0 +1,60 @@
+package poller
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestGraphiteBackend(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	defer listener.Close()
+
+	received := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				received <- scanner.Text()
+			}
+			conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	backend, err := NewGraphiteBackend(host, port, "acme.", time.Hour)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	check, _ := NewCheck("foobar", "10s", false, "", false, make(map[string]interface{}))
+	event := NewEvent(check)
+	event.Time = time.Unix(1327401316, 0)
+	event.Duration = 345 * time.Millisecond
+	event.Up()
+	backend.Log(event)
+	backend.Close()
+	// Closing twice should not panic
+	backend.Close()
+
+	for _, expected := range []string{"acme.foobar.duration 345 1327401316", "acme.foobar.up 1 1327401316"} {
+		select {
+		case line := <-received:
+			if line != expected {
+				t.Errorf("Line should be %q. Got %q", expected, line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("Line %q was not received", expected)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package poller
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Maximum size of the UDP packets sent to InfluxDB
+const influxDBMaxPacketSize = 8192
+
+// Backend for InfluxDB
+type influxDBBackend struct {
+	write       func([]byte) error
+	conn        net.Conn // UDP connection, if any
+	measurement string
+	lines       *bytes.Buffer
+	mu          sync.Mutex
+	stop        chan int
+	done        chan int
+	closeOnce   sync.Once
+}
+
+// Instanciate a new Backend that will send data to InfluxDB in the line protocol.
+// If protocol is "http" (the default), address is the URL of the write endpoint (ie: http://localhost:8086/write?db=poller).
+// If protocol is "udp", address is the host:port of InfluxDB's UDP listener.
+// Points are written to a measurement named after prefix without its trailing dot, prefix defaults to "checks.".
+// The check's key, type and tags are written as tags, while up, duration_ms and status_code are written as fields.
+// Points are batched and flushed every flushInterval, which defaults to 10s.
+func NewInfluxDBBackend(protocol, address, prefix string, flushInterval time.Duration) (Backend, error) {
+	if address == "" {
+		return nil, fmt.Errorf("InfluxDB address cannot be empty")
+	}
+
+	if protocol == "" {
+		protocol = "http"
+	}
+
+	if prefix == "" {
+		prefix = "checks."
+	}
+
+	if flushInterval <= 0 {
+		flushInterval = 10 * time.Second
+	}
+
+	i := &influxDBBackend{
+		measurement: strings.TrimSuffix(prefix, "."),
+		lines:       new(bytes.Buffer),
+		stop:        make(chan int),
+		done:        make(chan int)}
+
+	switch protocol {
+	case "http":
+		i.write = influxDBHTTPWriter(address)
+	case "udp":
+		conn, err := net.Dial("udp", address)
+		if err != nil {
+			return nil, err
+		}
+		i.conn = conn
+		i.write = influxDBUDPWriter(conn)
+	default:
+		return nil, fmt.Errorf("InfluxDB protocol should be either http or udp")
+	}
+
+	go i.flushEvery(flushInterval)
+
+	return i, nil
+}
+
+func influxDBHTTPWriter(url string) func([]byte) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	return func(data []byte) error {
+		resp, err := client.Post(url, "text/plain; charset=utf-8", bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("InfluxDB responded with status code %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// Splits the lines in packets which are no bigger than influxDBMaxPacketSize, unless a single line is bigger
+func influxDBUDPWriter(conn net.Conn) func([]byte) error {
+	return func(data []byte) error {
+		packet := new(bytes.Buffer)
+		for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+			if packet.Len() > 0 && packet.Len()+len(line) > influxDBMaxPacketSize {
+				if _, err := conn.Write(packet.Bytes()); err != nil {
+					return err
+				}
+				packet.Reset()
+			}
+			packet.Write(line)
+		}
+		if packet.Len() > 0 {
+			if _, err := conn.Write(packet.Bytes()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (i *influxDBBackend) flushEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer close(i.done)
+
+	for {
+		select {
+		case <-ticker.C:
+			i.flush()
+		case <-i.stop:
+			i.flush()
+			return
+		}
+	}
+}
+
+func (i *influxDBBackend) flush() {
+	i.mu.Lock()
+	if i.lines.Len() == 0 {
+		i.mu.Unlock()
+		return
+	}
+	data := i.lines.Bytes()
+	i.lines = new(bytes.Buffer)
+	i.mu.Unlock()
+
+	if err := i.write(data); err != nil {
+		log.Println("Unable to write points to InfluxDB:", err)
+	}
+}
+
+var (
+	influxDBMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxDBTagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+// Returns the line protocol representation of the event
+func (i *influxDBBackend) line(e *Event) string {
+	tags := make(map[string]string)
+	for k, v := range e.Check.Tags {
+		tags[k] = v
+	}
+	tags["key"] = e.Check.Key
+	tags["type"] = string(e.Check.Type())
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		// Empty tag values are not allowed by the line protocol
+		if tags[k] != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	line := influxDBMeasurementEscaper.Replace(i.measurement)
+	for _, k := range keys {
+		line += "," + influxDBTagEscaper.Replace(k) + "=" + influxDBTagEscaper.Replace(tags[k])
+	}
+
+	durationMs := float64(e.Duration.Nanoseconds()) / float64(time.Millisecond)
+	line += fmt.Sprintf(" up=%di,duration_ms=%s,status_code=%di %d\n", btou(e.IsUp()), strconv.FormatFloat(durationMs, 'f', -1, 64), e.StatusCode, e.Time.UnixNano())
+
+	return line
+}
+
+func (i *influxDBBackend) Log(e *Event) {
+	line := i.line(e)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.lines.WriteString(line)
+}
+
+// Close flushes the pending points and closes the UDP connection, if any. It can be called more than once.
+func (i *influxDBBackend) Close() {
+	i.closeOnce.Do(func() {
+		close(i.stop)
+		<-i.done
+		if i.conn != nil {
+			i.conn.Close()
+		}
+	})
+}
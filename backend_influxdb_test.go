@@ -0,0 +1,87 @@
+package poller
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestInfluxDBEvent() *Event {
+	check, _ := NewCheckFromJSON([]byte(testJsonHttpCheck))
+	check.Tags = map[string]string{"team": "web ops"}
+	event := NewEvent(check)
+	event.Time = time.Unix(1465839830, 100400200)
+	event.Duration = 1500 * time.Microsecond
+	event.StatusCode = 200
+	event.Up()
+
+	return event
+}
+
+const testInfluxDBLine = "checks,key=connect_sensiolabs_com_api,team=web\\ ops,type=http up=1i,duration_ms=1.5,status_code=200i 1465839830100400200\n"
+
+func TestInfluxDBBackendHTTP(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- string(body)
+		w.WriteHeader(204)
+	}))
+	defer server.Close()
+
+	backend, err := NewInfluxDBBackend("http", server.URL+"/write?db=poller", "", time.Hour)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	backend.Log(newTestInfluxDBEvent())
+	backend.Log(newTestInfluxDBEvent())
+	backend.Close()
+
+	select {
+	case body := <-received:
+		if body != strings.Repeat(testInfluxDBLine, 2) {
+			t.Errorf("Body is wrong. Got %q", body)
+		}
+	default:
+		t.Error("Points should have been flushed on Close")
+	}
+}
+
+func TestInfluxDBBackendUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	defer conn.Close()
+
+	backend, err := NewInfluxDBBackend("udp", conn.LocalAddr().String(), "", 10*time.Millisecond)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	backend.Log(newTestInfluxDBEvent())
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if string(buf[:n]) != testInfluxDBLine {
+		t.Errorf("Packet is wrong. Got %q", buf[:n])
+	}
+
+	// Closing twice should not panic, and the connection is closed
+	backend.Close()
+	backend.Close()
+	if _, err := backend.(*influxDBBackend).conn.Write([]byte("foo")); err == nil {
+		t.Error("The UDP connection should be closed")
+	}
+}
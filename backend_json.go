@@ -0,0 +1,162 @@
+package poller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// JSON representation of an event, as written by the jsonBackend
+type jsonEvent struct {
+	Key        string                 `json:"key"`
+	Type       string                 `json:"type"`
+	Up         bool                   `json:"up"`
+	DurationMs float64                `json:"durationMs"`
+	StatusCode int                    `json:"statusCode"`
+	Time       time.Time              `json:"time"`
+	Alert      bool                   `json:"alert"`
+	NotifyFix  bool                   `json:"notifyFix"`
+	UpSince    *time.Time             `json:"upSince,omitempty"`
+	DownSince  *time.Time             `json:"downSince,omitempty"`
+	Tags       map[string]string      `json:"tags,omitempty"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+}
+
+func newJSONEvent(e *Event) *jsonEvent {
+	je := &jsonEvent{
+		Key:        e.Check.Key,
+		Type:       string(e.Check.Type()),
+		Up:         e.IsUp(),
+		DurationMs: float64(e.Duration.Nanoseconds()) / float64(time.Millisecond),
+		StatusCode: e.StatusCode,
+		Time:       e.Time,
+		Alert:      e.Alert,
+		NotifyFix:  e.NotifyFix,
+		Tags:       e.Check.Tags,
+		Details:    e.Details}
+
+	if !e.Check.UpSince.IsZero() {
+		upSince := e.Check.UpSince
+		je.UpSince = &upSince
+	}
+	if !e.Check.DownSince.IsZero() {
+		downSince := e.Check.DownSince
+		je.DownSince = &downSince
+	}
+
+	return je
+}
+
+// Backend writing one JSON object per event
+type jsonBackend struct {
+	path       string // empty when writing to stdout
+	maxSize    int64
+	maxBackups int
+	writer     io.Writer
+	file       *os.File
+	size       int64
+	mu         sync.Mutex
+}
+
+// Instantiates a Backend which writes each event as a JSON object on its own line.
+// If path is empty or "-", events are written to stdout. Otherwise, they are appended to the file at path,
+// which is rotated once it would grow bigger than maxSize bytes: path is renamed to path.1, path.1 to path.2
+// and so on, up to maxBackups files. A maxSize lower or equal to 0 disables rotation.
+func NewJSONBackend(path string, maxSize int64, maxBackups int) (Backend, error) {
+	if path == "" || path == "-" {
+		return &jsonBackend{writer: os.Stdout}, nil
+	}
+
+	if maxBackups <= 0 {
+		maxBackups = 1
+	}
+
+	b := &jsonBackend{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := b.open(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (b *jsonBackend) open() error {
+	file, err := os.OpenFile(b.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	b.file = file
+	b.writer = file
+	b.size = info.Size()
+
+	return nil
+}
+
+// The current file is moved aside before the backups are shifted, so that they are only shifted once it is
+// sure to take path.1's place. It is only closed once the new one is opened, so that events keep being
+// written on failure.
+func (b *jsonBackend) rotate() error {
+	rotating := b.path + ".rotating"
+	if err := os.Rename(b.path, rotating); err != nil {
+		return err
+	}
+
+	for i := b.maxBackups - 1; i > 0; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", b.path, i), fmt.Sprintf("%s.%d", b.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			os.Rename(rotating, b.path)
+			return err
+		}
+	}
+	if err := os.Rename(rotating, b.path+".1"); err != nil {
+		os.Rename(rotating, b.path)
+		return err
+	}
+
+	previous := b.file
+	if err := b.open(); err != nil {
+		return err
+	}
+
+	return previous.Close()
+}
+
+func (b *jsonBackend) Log(e *Event) {
+	data, err := json.Marshal(newJSONEvent(e))
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.file != nil && b.maxSize > 0 && b.size > 0 && b.size+int64(len(data)) > b.maxSize {
+		if err := b.rotate(); err != nil {
+			log.Printf("Unable to rotate %s: %s", b.path, err)
+			// Try again once another maxSize bytes are written
+			b.size = 0
+		}
+	}
+
+	n, _ := b.writer.Write(data)
+	b.size += int64(n)
+}
+
+func (b *jsonBackend) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.file != nil {
+		b.file.Close()
+	}
+}
@@ -0,0 +1,128 @@
+package poller
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJSONBackend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "poller")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.log")
+
+	backend, err := NewJSONBackend(path, 300, 2)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	check, _ := NewCheckFromJSON([]byte(testJsonHttpCheck))
+	for i := 0; i < 4; i++ {
+		event := NewEvent(check)
+		event.StatusCode = 500
+		event.Down()
+		backend.Log(event)
+	}
+	backend.Close()
+
+	data, _ := ioutil.ReadFile(path)
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	var event map[string]interface{}
+	if err := json.Unmarshal(lines[len(lines)-1], &event); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if event["key"] != "connect_sensiolabs_com_api" || event["type"] != "http" || event["up"] != false || event["statusCode"] != float64(500) {
+		t.Errorf("Event is wrong. Got %s", lines[len(lines)-1])
+	}
+	if _, ok := event["downSince"]; !ok {
+		t.Error("downSince should be set")
+	}
+	if _, ok := event["upSince"]; ok {
+		t.Error("upSince should not be set")
+	}
+
+	// Each line is about 250 bytes long, so every event triggered a rotation
+	for _, backup := range []string{path + ".1", path + ".2"} {
+		if _, err := os.Stat(backup); err != nil {
+			t.Errorf("%s should exist", backup)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Errorf("Only 2 backups should be kept")
+	}
+}
+
+func TestJSONBackendRotationFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "poller")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.log")
+
+	// The file cannot be renamed over a directory
+	os.MkdirAll(filepath.Join(path+".1", "foo"), 0755)
+
+	backend, err := NewJSONBackend(path, 300, 1)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	check, _ := NewCheckFromJSON([]byte(testJsonHttpCheck))
+	for i := 0; i < 4; i++ {
+		backend.Log(NewEvent(check))
+	}
+	backend.Close()
+
+	data, _ := ioutil.ReadFile(path)
+	if lines := bytes.Count(data, []byte("\n")); lines != 4 {
+		t.Errorf("Events should still be written when the rotation fails. Got %d lines", lines)
+	}
+}
+
+func TestJSONBackendRotationFailureKeepsBackups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "poller")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.log")
+
+	ioutil.WriteFile(path+".1", []byte("backup\n"), 0644)
+	// The current file cannot be moved aside over a directory
+	os.MkdirAll(filepath.Join(path+".rotating", "foo"), 0755)
+
+	backend, err := NewJSONBackend(path, 300, 2)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	check, _ := NewCheckFromJSON([]byte(testJsonHttpCheck))
+	for i := 0; i < 4; i++ {
+		backend.Log(NewEvent(check))
+	}
+	backend.Close()
+
+	if data, _ := ioutil.ReadFile(path + ".1"); string(data) != "backup\n" {
+		t.Errorf("Backups should not be shifted when the rotation fails. Got %q", data)
+	}
+	if _, err := os.Stat(path + ".2"); err == nil {
+		t.Error("Backups should not be shifted when the rotation fails")
+	}
+	if data, _ := ioutil.ReadFile(path); bytes.Count(data, []byte("\n")) != 4 {
+		t.Error("Events should still be written when the rotation fails")
+	}
+}
@@ -0,0 +1,81 @@
+package poller
+
+import (
+	"log"
+	"sync"
+)
+
+// Default size of each backend's queue in a multiBackend
+const defaultBackendQueueSize = 100
+
+// A multiBackend fans events out to several backends.
+// Each backend consumes its own bounded queue in its own goroutine, so that a slow backend can neither block
+// nor delay the others. When a backend's queue is full, events are dropped for that backend only.
+type multiBackend struct {
+	backends []Backend
+	queues   []chan *Event
+	wg       sync.WaitGroup
+	closed   bool
+	mu       sync.RWMutex
+}
+
+// Instantiates a Backend which logs each event to every backend.
+// queueSize is the number of events queued per backend, it defaults to 100 if lower or equal to 0.
+func NewMultiBackend(queueSize int, backends ...Backend) Backend {
+	if queueSize <= 0 {
+		queueSize = defaultBackendQueueSize
+	}
+
+	m := &multiBackend{backends: backends, queues: make([]chan *Event, len(backends))}
+	for i := range backends {
+		m.queues[i] = make(chan *Event, queueSize)
+		m.wg.Add(1)
+		go m.consume(backends[i], m.queues[i])
+	}
+
+	return m
+}
+
+func (m *multiBackend) consume(backend Backend, queue <-chan *Event) {
+	defer m.wg.Done()
+
+	for e := range queue {
+		backend.Log(e)
+	}
+}
+
+func (m *multiBackend) Log(e *Event) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return
+	}
+
+	for i, queue := range m.queues {
+		select {
+		case queue <- e:
+		default:
+			log.Printf("Backend %d queue is full, dropping event for %s", i, e.Check.Key)
+		}
+	}
+}
+
+// Close waits for every queued event to be logged, then closes every backend.
+func (m *multiBackend) Close() {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return
+	}
+	m.closed = true
+	for _, queue := range m.queues {
+		close(queue)
+	}
+	m.mu.Unlock()
+
+	m.wg.Wait()
+	for _, backend := range m.backends {
+		backend.Close()
+	}
+}
@@ -0,0 +1,64 @@
+package poller
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingTestBackend struct {
+	events []*Event
+	closed bool
+	block  chan int
+	mu     sync.Mutex
+}
+
+func (b *recordingTestBackend) Log(e *Event) {
+	if b.block != nil {
+		<-b.block
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, e)
+}
+
+func (b *recordingTestBackend) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+}
+
+func (b *recordingTestBackend) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.events)
+}
+
+func TestMultiBackend(t *testing.T) {
+	fast := &recordingTestBackend{}
+	slow := &recordingTestBackend{block: make(chan int)}
+	backend := NewMultiBackend(2, fast, slow)
+
+	check, _ := NewCheck("foobar", "10s", false, "", false, make(map[string]interface{}))
+	for i := 0; i < 5; i++ {
+		backend.Log(NewEvent(check))
+
+		// The fast backend receives every event even though the slow one is blocked
+		for j := 0; j < 100 && fast.len() <= i; j++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if fast.len() != 5 {
+		t.Errorf("Fast backend should have logged 5 events. Got %d", fast.len())
+	}
+
+	close(slow.block)
+	backend.Close()
+	// Events which did not fit in the slow backend's queue were dropped
+	if slow.len() < 2 || slow.len() > 3 {
+		t.Errorf("Slow backend should have logged 2 or 3 events. Got %d", slow.len())
+	}
+	if !fast.closed || !slow.closed {
+		t.Error("Every backend should be closed")
+	}
+}
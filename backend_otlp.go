@@ -0,0 +1,269 @@
+package poller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Upper bounds, in milliseconds, of the duration histogram's buckets
+var otlpDurationBounds = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// AGGREGATION_TEMPORALITY_CUMULATIVE in the OTLP protocol
+const otlpCumulativeTemporality = 2
+
+// Instruments of a check, identified by its key
+type otlpCheckInstruments struct {
+	attributes []otlpAttribute
+	up         int64
+	lastCheck  time.Time
+	start      time.Time
+	buckets    []uint64 // non cumulative count of durations per bucket, the last one being +Inf
+	sum        float64
+	count      uint64
+}
+
+// Backend exporting metrics to an OpenTelemetry collector with OTLP/HTTP, in its JSON encoding
+type otlpBackend struct {
+	endpoint    string
+	headers     map[string]string
+	serviceName string
+	client      *http.Client
+	instruments map[string]*otlpCheckInstruments
+	mu          sync.Mutex
+	stop        chan int
+	done        chan int
+	once        sync.Once
+}
+
+// Instanciate a new Backend that records checks results as OpenTelemetry instruments and exports them
+// to the collector at endpoint (ie: http://localhost:4318) over OTLP/HTTP. If endpoint has no path,
+// metrics are sent to /v1/metrics. headers are added to every export request.
+// Two instruments are recorded, with the check's key, type and tags as attributes:
+// * poller.check.up: gauge, 1 if the check is up, 0 otherwise.
+// * poller.check.duration: histogram of the checks durations, in milliseconds.
+// Metrics are exported every exportInterval, which defaults to 10s, and on Close.
+func NewOTLPBackend(endpoint, serviceName string, headers map[string]string, exportInterval time.Duration) (Backend, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("OTLP endpoint should be an http or https URL")
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/metrics"
+	}
+
+	if serviceName == "" {
+		serviceName = "poller"
+	}
+
+	if exportInterval <= 0 {
+		exportInterval = 10 * time.Second
+	}
+
+	o := &otlpBackend{
+		endpoint:    u.String(),
+		headers:     headers,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		instruments: make(map[string]*otlpCheckInstruments),
+		stop:        make(chan int),
+		done:        make(chan int)}
+
+	go o.exportEvery(exportInterval)
+
+	return o, nil
+}
+
+func (o *otlpBackend) Log(e *Event) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	instruments, ok := o.instruments[e.Check.Key]
+	if !ok {
+		instruments = &otlpCheckInstruments{start: e.Time, buckets: make([]uint64, len(otlpDurationBounds)+1)}
+		o.instruments[e.Check.Key] = instruments
+	}
+
+	instruments.attributes = otlpCheckAttributes(e.Check)
+	instruments.up = btou(e.IsUp())
+	instruments.lastCheck = e.Time
+
+	duration := float64(e.Duration.Nanoseconds()) / float64(time.Millisecond)
+	instruments.buckets[sort.SearchFloat64s(otlpDurationBounds, duration)]++
+	instruments.sum += duration
+	instruments.count++
+}
+
+func (o *otlpBackend) exportEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer close(o.done)
+
+	for {
+		select {
+		case <-ticker.C:
+			o.export()
+		case <-o.stop:
+			o.export()
+			return
+		}
+	}
+}
+
+func (o *otlpBackend) export() {
+	data, err := o.request()
+	if data == nil || err != nil {
+		return
+	}
+
+	req, err := http.NewRequest("POST", o.endpoint, bytes.NewReader(data))
+	if err != nil {
+		log.Println("Unable to export metrics to OTLP collector:", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range o.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		log.Println("Unable to export metrics to OTLP collector:", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("Unable to export metrics to OTLP collector: status code %d", resp.StatusCode)
+	}
+}
+
+// Close exports the metrics one last time. It can be called more than once.
+func (o *otlpBackend) Close() {
+	o.once.Do(func() {
+		close(o.stop)
+	})
+	<-o.done
+}
+
+// JSON encoding of the OTLP ExportMetricsServiceRequest message.
+// As per the protobuf JSON mapping, 64 bits integers are encoded as strings.
+type otlpAttribute struct {
+	Key   string            `json:"key"`
+	Value map[string]string `json:"value"`
+}
+
+type otlpDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsInt             string          `json:"asInt,omitempty"`
+	Count             string          `json:"count,omitempty"`
+	Sum               *float64        `json:"sum,omitempty"`
+	BucketCounts      []string        `json:"bucketCounts,omitempty"`
+	ExplicitBounds    []float64       `json:"explicitBounds,omitempty"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpHistogram struct {
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Unit        string         `json:"unit"`
+	Gauge       *otlpGauge     `json:"gauge,omitempty"`
+	Histogram   *otlpHistogram `json:"histogram,omitempty"`
+}
+
+func otlpCheckAttributes(c *Check) []otlpAttribute {
+	attributes := []otlpAttribute{
+		{Key: "check.key", Value: map[string]string{"stringValue": c.Key}},
+		{Key: "check.type", Value: map[string]string{"stringValue": string(c.Type())}}}
+
+	keys := make([]string, 0, len(c.Tags))
+	for k := range c.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attributes = append(attributes, otlpAttribute{Key: "check.tag." + k, Value: map[string]string{"stringValue": c.Tags[k]}})
+	}
+
+	return attributes
+}
+
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// Returns the JSON encoded export request, or nil if no check was recorded yet
+func (o *otlpBackend) request() ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.instruments) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(o.instruments))
+	for k := range o.instruments {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	up := otlpMetric{Name: "poller.check.up", Description: "Whether the check is up.", Unit: "1", Gauge: &otlpGauge{}}
+	duration := otlpMetric{
+		Name:        "poller.check.duration",
+		Description: "Duration of the checks.",
+		Unit:        "ms",
+		Histogram:   &otlpHistogram{AggregationTemporality: otlpCumulativeTemporality}}
+
+	for _, k := range keys {
+		i := o.instruments[k]
+		up.Gauge.DataPoints = append(up.Gauge.DataPoints, otlpDataPoint{
+			Attributes:   i.attributes,
+			TimeUnixNano: otlpTime(i.lastCheck),
+			AsInt:        strconv.FormatInt(i.up, 10)})
+
+		bucketCounts := make([]string, len(i.buckets))
+		for j, count := range i.buckets {
+			bucketCounts[j] = strconv.FormatUint(count, 10)
+		}
+		sum := i.sum
+		duration.Histogram.DataPoints = append(duration.Histogram.DataPoints, otlpDataPoint{
+			Attributes:        i.attributes,
+			StartTimeUnixNano: otlpTime(i.start),
+			TimeUnixNano:      otlpTime(i.lastCheck),
+			Count:             strconv.FormatUint(i.count, 10),
+			Sum:               &sum,
+			BucketCounts:      bucketCounts,
+			ExplicitBounds:    otlpDurationBounds})
+	}
+
+	request := map[string]interface{}{
+		"resourceMetrics": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": []otlpAttribute{{Key: "service.name", Value: map[string]string{"stringValue": o.serviceName}}}},
+				"scopeMetrics": []interface{}{
+					map[string]interface{}{
+						"scope":   map[string]string{"name": "github.com/marcw/poller"},
+						"metrics": []otlpMetric{up, duration}}}}}}
+
+	return json.Marshal(request)
+}
@@ -0,0 +1,76 @@
+package poller
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOTLPBackend(t *testing.T) {
+	received := make(chan map[string]interface{}, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" || r.Header.Get("Content-Type") != "application/json" || r.Header.Get("X-Api-Key") != "secret" {
+			http.Error(w, "bad request", 400)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		var request map[string]interface{}
+		json.Unmarshal(body, &request)
+		received <- request
+	}))
+	defer collector.Close()
+
+	backend, err := NewOTLPBackend(collector.URL, "", map[string]string{"X-Api-Key": "secret"}, time.Hour)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	check, _ := NewCheckFromJSON([]byte(testJsonHttpCheck))
+	for _, duration := range []time.Duration{7 * time.Millisecond, 300 * time.Millisecond} {
+		event := NewEvent(check)
+		event.Duration = duration
+		event.Up()
+		backend.Log(event)
+	}
+	backend.Close()
+	// Closing twice, ie: through a multi backend then directly, should not panic
+	backend.Close()
+
+	var request map[string]interface{}
+	select {
+	case request = <-received:
+	default:
+		t.Log("Metrics should have been exported on Close")
+		t.FailNow()
+	}
+
+	resource := request["resourceMetrics"].([]interface{})[0].(map[string]interface{})
+	metrics := resource["scopeMetrics"].([]interface{})[0].(map[string]interface{})["metrics"].([]interface{})
+	if len(metrics) != 2 {
+		t.Log("2 metrics should have been exported")
+		t.FailNow()
+	}
+
+	up := metrics[0].(map[string]interface{})
+	point := up["gauge"].(map[string]interface{})["dataPoints"].([]interface{})[0].(map[string]interface{})
+	if up["name"] != "poller.check.up" || point["asInt"] != "1" {
+		t.Errorf("up is wrong. Got %v", up)
+	}
+	attribute := point["attributes"].([]interface{})[0].(map[string]interface{})
+	if attribute["key"] != "check.key" || attribute["value"].(map[string]interface{})["stringValue"] != "connect_sensiolabs_com_api" {
+		t.Errorf("check.key attribute is wrong. Got %v", attribute)
+	}
+
+	duration := metrics[1].(map[string]interface{})
+	point = duration["histogram"].(map[string]interface{})["dataPoints"].([]interface{})[0].(map[string]interface{})
+	if duration["name"] != "poller.check.duration" || point["count"] != "2" || point["sum"] != float64(307) {
+		t.Errorf("duration is wrong. Got %v", duration)
+	}
+	buckets := point["bucketCounts"].([]interface{})
+	if buckets[1] != "1" || buckets[6] != "1" {
+		t.Errorf("bucketCounts are wrong. Got %v", buckets)
+	}
+}
@@ -0,0 +1,155 @@
+package poller
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Upper bounds, in seconds, of the duration histogram's buckets
+var prometheusDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Number of intervals a check can miss before its metrics are dropped, ie: once it has been removed
+const prometheusMissedIntervals = 3
+
+// A PrometheusBackend is a Backend which serves the latest state of every check in the Prometheus text
+// exposition format.
+type PrometheusBackend interface {
+	Backend
+	http.Handler
+}
+
+// Latest state of a check
+type prometheusCheckState struct {
+	checkType           CheckType
+	interval            time.Duration
+	up                  bool
+	statusCode          int
+	lastCheck           time.Time
+	consecutiveFailures int
+	buckets             []uint64 // non cumulative count of durations per bucket, the last one being +Inf
+	durationSum         float64
+	durationCount       uint64
+}
+
+type prometheusBackend struct {
+	prefix string
+	states map[string]*prometheusCheckState
+	mu     sync.Mutex
+}
+
+// Instantiates a PrometheusBackend. Metrics are named after prefix, which defaults to "poller_".
+// For each check, the following metrics are exposed with "key" and "type" labels:
+// * <prefix>check_up: 1 if the check is up, 0 otherwise. It has a "status_code" label as well.
+// * <prefix>check_duration_seconds: histogram of the checks durations.
+// * <prefix>check_last_timestamp_seconds: unix time of the last check.
+// * <prefix>check_consecutive_failures: number of failed checks since the check was last up.
+// The metrics of a check are dropped once it has not been polled for 3 of its intervals, ie: once it was removed.
+func NewPrometheusBackend(prefix string) PrometheusBackend {
+	if prefix == "" {
+		prefix = "poller_"
+	}
+
+	return &prometheusBackend{prefix: prefix, states: make(map[string]*prometheusCheckState)}
+}
+
+func (p *prometheusBackend) Log(e *Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, ok := p.states[e.Check.Key]
+	if !ok {
+		state = &prometheusCheckState{buckets: make([]uint64, len(prometheusDurationBuckets)+1)}
+		p.states[e.Check.Key] = state
+	}
+
+	state.checkType = e.Check.Type()
+	state.interval = e.Check.Interval
+	state.up = e.IsUp()
+	state.statusCode = e.StatusCode
+	state.lastCheck = e.Time
+	if e.IsUp() {
+		state.consecutiveFailures = 0
+	} else {
+		state.consecutiveFailures++
+	}
+
+	duration := e.Duration.Seconds()
+	state.buckets[sort.SearchFloat64s(prometheusDurationBuckets, duration)]++
+	state.durationSum += duration
+	state.durationCount++
+}
+
+func (p *prometheusBackend) Close() {
+	// NO OP
+}
+
+func (p *prometheusBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(p.exposition())
+}
+
+// Renders every metric in the text exposition format
+func (p *prometheusBackend) exposition() []byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	keys := make([]string, 0, len(p.states))
+	for k, s := range p.states {
+		if s.interval > 0 && now.Sub(s.lastCheck) > prometheusMissedIntervals*s.interval {
+			delete(p.states, k)
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buffer := new(bytes.Buffer)
+
+	fmt.Fprintf(buffer, "# HELP %scheck_up Whether the check is up.\n# TYPE %scheck_up gauge\n", p.prefix, p.prefix)
+	for _, k := range keys {
+		s := p.states[k]
+		fmt.Fprintf(buffer, "%scheck_up{%s,status_code=\"%d\"} %d\n", p.prefix, prometheusLabels(k, s), s.statusCode, btou(s.up))
+	}
+
+	fmt.Fprintf(buffer, "# HELP %scheck_duration_seconds Duration of the checks.\n# TYPE %scheck_duration_seconds histogram\n", p.prefix, p.prefix)
+	for _, k := range keys {
+		s := p.states[k]
+		labels := prometheusLabels(k, s)
+		var cumulative uint64
+		for i, bound := range prometheusDurationBuckets {
+			cumulative += s.buckets[i]
+			fmt.Fprintf(buffer, "%scheck_duration_seconds_bucket{%s,le=\"%s\"} %d\n", p.prefix, labels, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(buffer, "%scheck_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", p.prefix, labels, s.durationCount)
+		fmt.Fprintf(buffer, "%scheck_duration_seconds_sum{%s} %s\n", p.prefix, labels, strconv.FormatFloat(s.durationSum, 'g', -1, 64))
+		fmt.Fprintf(buffer, "%scheck_duration_seconds_count{%s} %d\n", p.prefix, labels, s.durationCount)
+	}
+
+	fmt.Fprintf(buffer, "# HELP %scheck_last_timestamp_seconds Unix time of the last check.\n# TYPE %scheck_last_timestamp_seconds gauge\n", p.prefix, p.prefix)
+	for _, k := range keys {
+		s := p.states[k]
+		timestamp := float64(s.lastCheck.UnixNano()) / float64(time.Second)
+		fmt.Fprintf(buffer, "%scheck_last_timestamp_seconds{%s} %s\n", p.prefix, prometheusLabels(k, s), strconv.FormatFloat(timestamp, 'f', 3, 64))
+	}
+
+	fmt.Fprintf(buffer, "# HELP %scheck_consecutive_failures Number of failed checks since the check was last up.\n# TYPE %scheck_consecutive_failures gauge\n", p.prefix, p.prefix)
+	for _, k := range keys {
+		s := p.states[k]
+		fmt.Fprintf(buffer, "%scheck_consecutive_failures{%s} %d\n", p.prefix, prometheusLabels(k, s), s.consecutiveFailures)
+	}
+
+	return buffer.Bytes()
+}
+
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func prometheusLabels(key string, s *prometheusCheckState) string {
+	return fmt.Sprintf("key=\"%s\",type=\"%s\"", prometheusLabelEscaper.Replace(key), prometheusLabelEscaper.Replace(string(s.checkType)))
+}
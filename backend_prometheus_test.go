@@ -0,0 +1,77 @@
+package poller
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusBackend(t *testing.T) {
+	backend := NewPrometheusBackend("")
+
+	check, _ := NewCheckFromJSON([]byte(testJsonHttpCheck))
+	event := NewEvent(check)
+	event.Duration = 30 * time.Millisecond
+	event.StatusCode = 500
+	event.Down()
+	backend.Log(event)
+	event = NewEvent(check)
+	event.Duration = 2 * time.Second
+	event.StatusCode = 503
+	event.Down()
+	backend.Log(event)
+
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	labels := `key="connect_sensiolabs_com_api",type="http"`
+	expected := []string{
+		`poller_check_up{` + labels + `,status_code="503"} 0`,
+		`poller_check_duration_seconds_bucket{` + labels + `,le="0.025"} 0`,
+		`poller_check_duration_seconds_bucket{` + labels + `,le="0.05"} 1`,
+		`poller_check_duration_seconds_bucket{` + labels + `,le="+Inf"} 2`,
+		`poller_check_duration_seconds_sum{` + labels + `} 2.03`,
+		`poller_check_duration_seconds_count{` + labels + `} 2`,
+		`poller_check_consecutive_failures{` + labels + `} 2`,
+		`poller_check_last_timestamp_seconds{` + labels + `} `,
+	}
+	for _, line := range expected {
+		if !strings.Contains(string(body), line) {
+			t.Errorf("Exposition should contain %s", line)
+		}
+	}
+	if strings.Contains(string(body), `status_code="500"`) {
+		t.Error("Only the latest status code should be exposed")
+	}
+}
+
+func TestPrometheusBackendRemovedCheck(t *testing.T) {
+	backend := NewPrometheusBackend("").(*prometheusBackend)
+
+	check, _ := NewCheck("removed", "10s", false, "", false, make(map[string]interface{}))
+	event := NewEvent(check)
+	event.Up()
+	backend.Log(event)
+	if !strings.Contains(string(backend.exposition()), `key="removed"`) {
+		t.Log("The check should be exposed")
+		t.FailNow()
+	}
+
+	// The check has not been polled for more than 3 intervals
+	backend.states["removed"].lastCheck = time.Now().Add(-31 * time.Second)
+	if strings.Contains(string(backend.exposition()), `key="removed"`) {
+		t.Error("The metrics of a check which is no longer polled should be dropped")
+	}
+	if len(backend.states) != 0 {
+		t.Error("The state of a check which is no longer polled should be dropped")
+	}
+}
@@ -4,16 +4,37 @@ import (
 	"fmt"
 	"github.com/peterbourgon/g2s"
 	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Options of the statsd backend
+type StatsdOptions struct {
+	UpAsGauge bool              // Send .up as a gauge instead of a counter
+	DogStatsD bool              // Use the DogStatsD dialect, see NewStatsdBackendWithOptions
+	Tags      map[string]string // Tags added to every metric in the DogStatsD dialect
+}
+
 // Backend for Statsd
 type statsdBackend struct {
-	statsd g2s.Statter
-	prefix string
+	statsd   g2s.Statter
+	conn     net.Conn // used instead of statsd in the DogStatsD dialect
+	protocol string
+	prefix   string
+	options  StatsdOptions
 }
 
 // Instanciate a new Backend that will send data to a statsd instance
 func NewStatsdBackend(host, port, protocol, prefix string) (Backend, error) {
+	return NewStatsdBackendWithOptions(host, port, protocol, prefix, StatsdOptions{})
+}
+
+// Instanciate a new Backend that will send data to a statsd instance, according to options.
+// In the DogStatsD dialect, metrics are named <prefix>duration and <prefix>up, .up is always sent as a gauge,
+// and the check's key, type and tags are sent as tags along with the options' tags.
+func NewStatsdBackendWithOptions(host, port, protocol, prefix string, options StatsdOptions) (Backend, error) {
 	if host == "" {
 		return nil, fmt.Errorf("Statsd host cannot be empty")
 	}
@@ -30,19 +51,81 @@ func NewStatsdBackend(host, port, protocol, prefix string) (Backend, error) {
 		prefix = "checks."
 	}
 
+	if options.DogStatsD {
+		conn, err := net.Dial(protocol, net.JoinHostPort(host, port))
+		if err != nil {
+			return nil, err
+		}
+
+		return &statsdBackend{conn: conn, protocol: protocol, prefix: prefix, options: options}, nil
+	}
+
 	statsd, err := g2s.Dial(protocol, net.JoinHostPort(host, port))
 	if err != nil {
 		return nil, err
 	}
 
-	return &statsdBackend{statsd: statsd, prefix: prefix}, nil
+	return &statsdBackend{statsd: statsd, protocol: protocol, prefix: prefix, options: options}, nil
 }
 
 func (s *statsdBackend) Log(e *Event) {
+	if s.options.DogStatsD {
+		s.logDogStatsD(e)
+		return
+	}
+
 	s.statsd.Timing(1.0, s.prefix+e.Check.Key+".duration", e.Duration)
-	s.statsd.Counter(1.0, s.prefix+e.Check.Key+".up", int(btou(e.IsUp())))
+	if s.options.UpAsGauge {
+		s.statsd.Gauge(1.0, s.prefix+e.Check.Key+".up", strconv.FormatInt(btou(e.IsUp()), 10))
+	} else {
+		s.statsd.Counter(1.0, s.prefix+e.Check.Key+".up", int(btou(e.IsUp())))
+	}
+}
+
+func (s *statsdBackend) logDogStatsD(e *Event) {
+	tags := s.dogStatsDTags(e.Check)
+	s.send(fmt.Sprintf("%sduration:%d|ms|#%s", s.prefix, e.Duration.Nanoseconds()/int64(time.Millisecond), tags))
+	s.send(fmt.Sprintf("%sup:%d|g|#%s", s.prefix, btou(e.IsUp()), tags))
+}
+
+func (s *statsdBackend) send(metric string) {
+	// Metrics are delimited by packets over UDP, by new lines over TCP
+	if !strings.HasPrefix(s.protocol, "udp") {
+		metric += "\n"
+	}
+	s.conn.Write([]byte(metric))
+}
+
+var dogStatsDTagEscaper = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
+
+// Returns the check's tags in the DogStatsD format, ie: "key:foobar,type:http,team:web"
+func (s *statsdBackend) dogStatsDTags(c *Check) string {
+	tags := make(map[string]string)
+	for k, v := range s.options.Tags {
+		tags[k] = v
+	}
+	for k, v := range c.Tags {
+		tags[k] = v
+	}
+	tags["key"] = c.Key
+	tags["type"] = string(c.Type())
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	formatted := make([]string, 0, len(keys))
+	for _, k := range keys {
+		formatted = append(formatted, dogStatsDTagEscaper.Replace(k)+":"+dogStatsDTagEscaper.Replace(tags[k]))
+	}
+
+	return strings.Join(formatted, ",")
 }
 
 func (s *statsdBackend) Close() {
-	// NO OP
+	if s.conn != nil {
+		s.conn.Close()
+	}
 }
@@ -0,0 +1,84 @@
+package poller
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func newTestStatsdServer(t *testing.T) (net.PacketConn, string, string) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	host, port, _ := net.SplitHostPort(conn.LocalAddr().String())
+
+	return conn, host, port
+}
+
+func readTestStatsdPackets(t *testing.T, conn net.PacketConn, count int) []string {
+	packets := make([]string, 0, count)
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for len(packets) < count {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Log(err)
+			t.FailNow()
+		}
+		packets = append(packets, string(buf[:n]))
+	}
+
+	return packets
+}
+
+func newTestStatsdEvent() *Event {
+	check, _ := NewCheckFromJSON([]byte(testJsonHttpCheck))
+	check.Tags = map[string]string{"team": "web"}
+	event := NewEvent(check)
+	event.Duration = 345 * time.Millisecond
+	event.Up()
+
+	return event
+}
+
+func TestStatsdBackendUpAsGauge(t *testing.T) {
+	conn, host, port := newTestStatsdServer(t)
+	defer conn.Close()
+
+	backend, err := NewStatsdBackendWithOptions(host, port, "udp", "", StatsdOptions{UpAsGauge: true})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	defer backend.Close()
+	backend.Log(newTestStatsdEvent())
+
+	packets := readTestStatsdPackets(t, conn, 2)
+	if packets[1] != "checks.connect_sensiolabs_com_api.up:1|g" {
+		t.Errorf("up should be sent as a gauge. Got %q", packets[1])
+	}
+}
+
+func TestStatsdBackendDogStatsD(t *testing.T) {
+	conn, host, port := newTestStatsdServer(t)
+	defer conn.Close()
+
+	backend, err := NewStatsdBackendWithOptions(host, port, "udp", "poller.", StatsdOptions{DogStatsD: true, Tags: map[string]string{"env": "prod"}})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	defer backend.Close()
+	backend.Log(newTestStatsdEvent())
+
+	packets := readTestStatsdPackets(t, conn, 2)
+	tags := "|#env:prod,key:connect_sensiolabs_com_api,team:web,type:http"
+	if packets[0] != "poller.duration:345|ms"+tags {
+		t.Errorf("duration is wrong. Got %q", packets[0])
+	}
+	if packets[1] != "poller.up:1|g"+tags {
+		t.Errorf("up is wrong. Got %q", packets[1])
+	}
+}
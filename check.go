@@ -11,6 +11,10 @@ type CheckType string
 const (
 	CheckTypeUDP  CheckType = "udp"
 	CheckTypeHTTP CheckType = "http"
+	CheckTypeTCP  CheckType = "tcp"
+	CheckTypeTLS  CheckType = "tls"
+	CheckTypeDNS  CheckType = "dns"
+	CheckTypePing CheckType = "ping"
 )
 
 type Check struct {
@@ -18,6 +22,7 @@ type Check struct {
 	checkType CheckType // Type of check
 
 	Interval time.Duration // Interval between each check
+	Timeout  time.Duration // Timeout of the check (zero value = probe's timeout)
 
 	UpSince    time.Time     // Time since the service is up
 	DownSince  time.Time     // Time since the service is down
@@ -29,7 +34,21 @@ type Check struct {
 	NotifyFix bool // Notify if service is back up
 
 	AlertDelay time.Duration // Delay before raising an alert (zero value = NOW)
-	Config     *bag.Bag
+	Alerters   []string      // Names of the alerters receiving the check's alerts (empty = default alerters)
+
+	Escalation   []EscalationStep // Alerters notified as the downtime goes on, sorted by delay (replaces AlertDelay and Alerters)
+	Escalated    int              // Number of escalation steps already notified
+	Acknowledged bool             // Has the downtime been acknowledged? Stops the escalation
+
+	Tags   map[string]string // User defined tags, ie: {"team": "payments"}
+	Config *bag.Bag
+}
+
+// An EscalationStep notifies its alerters once the check has been down for After,
+// unless the downtime has been acknowledged.
+type EscalationStep struct {
+	After    time.Duration
+	Alerters []string
 }
 
 func newCheck() *Check {
@@ -55,7 +74,30 @@ func NewCheck(key, interval string, alert bool, alertDelay string, notifyFix boo
 
 // Check if it's time to send the alert. Returns true if it is.
 func (c *Check) ShouldAlert() bool {
-	return c.Alert && !c.Alerted && c.DownSince.Add(c.AlertDelay).Before(time.Now())
+	return c.Alert && !c.Alerted && !c.DownSince.Add(c.AlertDelay).After(time.Now())
+}
+
+// Returns the number of escalation steps which are due at t.
+func (c *Check) dueEscalationSteps(t time.Time) int {
+	due := 0
+	for _, step := range c.Escalation {
+		if c.DownSince.Add(step.After).After(t) {
+			break
+		}
+		due++
+	}
+
+	return due
+}
+
+// Acknowledges the current downtime, which stops its escalation. Returns false if the check is not down.
+func (c *Check) Acknowledge() bool {
+	if c.DownSince.IsZero() {
+		return false
+	}
+	c.Acknowledged = true
+
+	return true
 }
 
 func (c *Check) ShouldNotifyFix() bool {
@@ -74,13 +116,29 @@ func (c *Check) ShouldNotifyFix() bool {
 	return false
 }
 
+// Returns the check's timeout, or fallback if the check does not define its own.
+func (c *Check) TimeoutOr(fallback time.Duration) time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+
+	return fallback
+}
+
 func (c *Check) Type() CheckType {
 	return c.checkType
 }
 
 func (c *Check) AlertDescription() string {
-	if c.Type() == CheckTypeHTTP {
+	switch c.Type() {
+	case CheckTypeHTTP:
 		return fmt.Sprintf("%s (%s)", c.Key, c.Config.GetString("url"))
+	case CheckTypeTCP, CheckTypeTLS:
+		return fmt.Sprintf("%s (%s:%d)", c.Key, c.Config.GetString("host"), c.Config.GetInt("port"))
+	case CheckTypePing:
+		return fmt.Sprintf("%s (%s)", c.Key, c.Config.GetString("host"))
+	case CheckTypeDNS:
+		return fmt.Sprintf("%s (%s %s @%s)", c.Key, c.Config.GetString("recordType"), c.Config.GetString("name"), c.Config.GetString("nameserver"))
 	}
 
 	return ""
@@ -4,28 +4,58 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/bitly/go-simplejson"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
-type checkConfigurator func(*Check, *simplejson.Json) error
+// A CheckConfigurator reads the type specific part of a check's JSON definition into the check's Config.
+type CheckConfigurator func(*Check, *simplejson.Json) error
 
-var checkConfigurators = map[CheckType]checkConfigurator{
+var checkConfiguratorsMu sync.RWMutex
+var checkConfigurators = map[CheckType]CheckConfigurator{
 	CheckTypeUDP:  readUDPConfig,
-	CheckTypeHTTP: readHTTPConfig}
+	CheckTypeHTTP: readHTTPConfig,
+	CheckTypeTCP:  readTCPConfig,
+	CheckTypeTLS:  readTLSConfig,
+	CheckTypeDNS:  readDNSConfig,
+	CheckTypePing: readPingConfig}
 
-// Used for marshalling / unmarshalling
+// RegisterCheckConfigurator makes NewCheckFromJSON read checks of type t with configurator.
+// An error is returned if a configurator is already registered for t.
+func RegisterCheckConfigurator(t CheckType, configurator CheckConfigurator) error {
+	checkConfiguratorsMu.Lock()
+	defer checkConfiguratorsMu.Unlock()
+
+	if _, ok := checkConfigurators[t]; ok {
+		return fmt.Errorf("A configurator is already registered for check type %s", t)
+	}
+	checkConfigurators[t] = configurator
+
+	return nil
+}
+
+// Used for marshalling
 type jsonCheck struct {
 	Type       string                 `json:"type"`
 	Key        string                 `json:"key"`
 	Interval   string                 `json:"interval"`
+	Timeout    string                 `json:"timeout,omitempty"`
 	Alert      bool                   `json:"alert"`
 	AlertDelay string                 `json:"alertDelay"`
 	NotifyFix  bool                   `json:"notifyFix"`
+	Alerters   []string               `json:"alerters,omitempty"`
+	Escalation []jsonEscalationStep   `json:"escalation,omitempty"`
+	Tags       map[string]string      `json:"tags,omitempty"`
 	Config     map[string]interface{} `json:"config"`
 }
 
-func (c *jsonCheck) toCheck() (*Check, error) {
-	return NewCheck(c.Key, c.Interval, c.Alert, c.AlertDelay, c.NotifyFix, c.Config)
+type jsonEscalationStep struct {
+	After    string   `json:"after"`
+	Alerters []string `json:"alerters"`
 }
 
 // Returns a jsonCheck object used internally before marshalling check to JSON
@@ -37,7 +67,15 @@ func (c *Check) json() *jsonCheck {
 		NotifyFix:  c.NotifyFix,
 		Alert:      c.Alert,
 		AlertDelay: c.AlertDelay.String(),
+		Alerters:   c.Alerters,
+		Tags:       c.Tags,
 		Config:     c.Config.Map()}
+	if c.Timeout > 0 {
+		check.Timeout = c.Timeout.String()
+	}
+	for _, step := range c.Escalation {
+		check.Escalation = append(check.Escalation, jsonEscalationStep{After: step.After.String(), Alerters: step.Alerters})
+	}
 
 	return check
 }
@@ -101,7 +139,61 @@ func NewCheckFromJSON(data []byte) (*Check, error) {
 		check.Interval = interval
 	}
 
+	// timeout is optional, the probe's timeout is used by default
+	if js, ok := js.CheckGet("timeout"); ok {
+		timeout, err := js.String()
+		if err != nil {
+			return nil, err
+		}
+		if check.Timeout, err = time.ParseDuration(timeout); err != nil {
+			return nil, err
+		}
+	}
+
+	// tags are optional
+	if js, ok := js.CheckGet("tags"); ok {
+		tags, err := js.Map()
+		if err != nil {
+			return nil, err
+		}
+		check.Tags = make(map[string]string)
+		for k, v := range tags {
+			value, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("Tags can only accept string.")
+			}
+			check.Tags[k] = value
+		}
+	}
+
+	// alerters are optional, the default alerters are used when none is referenced
+	if js, ok := js.CheckGet("alerters"); ok {
+		alerters, err := js.StringArray()
+		if err != nil {
+			return nil, fmt.Errorf("Alerters can only accept string.")
+		}
+		check.Alerters = alerters
+	}
+
+	// escalation is optional
+	if js, ok := js.CheckGet("escalation"); ok {
+		steps, err := js.Array()
+		if err != nil {
+			return nil, fmt.Errorf("Escalation should be an array of steps.")
+		}
+		for i := range steps {
+			step, err := readEscalationStep(js.GetIndex(i))
+			if err != nil {
+				return nil, err
+			}
+			check.Escalation = append(check.Escalation, step)
+		}
+		sortEscalation(check.Escalation)
+	}
+
+	checkConfiguratorsMu.RLock()
 	configurator, ok := checkConfigurators[check.Type()]
+	checkConfiguratorsMu.RUnlock()
 	if !ok {
 		// TODO: Be nice to the user and try to guess what he meant
 		return nil, fmt.Errorf("Unknown check type %s", checkType)
@@ -113,6 +205,32 @@ func NewCheckFromJSON(data []byte) (*Check, error) {
 	return check, nil
 }
 
+// Reads an escalation step, ie: {"after": "5m", "alerters": ["smtp-oncall"]}
+func readEscalationStep(js *simplejson.Json) (EscalationStep, error) {
+	step := EscalationStep{}
+
+	after, err := js.Get("after").String()
+	if err != nil {
+		return step, fmt.Errorf("Escalation steps should define after.")
+	}
+	if step.After, err = time.ParseDuration(after); err != nil {
+		return step, err
+	}
+
+	if step.Alerters, err = js.Get("alerters").StringArray(); err != nil || len(step.Alerters) == 0 {
+		return step, fmt.Errorf("Escalation steps should define alerters.")
+	}
+
+	return step, nil
+}
+
+// Sorts escalation steps by delay, steps with the same delay keeping their order
+func sortEscalation(steps []EscalationStep) {
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].After < steps[j].After
+	})
+}
+
 func readHTTPConfig(check *Check, js *simplejson.Json) error {
 	if url, err := js.Get("config").Get("url").String(); err != nil {
 		return err
@@ -128,8 +246,137 @@ func readHTTPConfig(check *Check, js *simplejson.Json) error {
 		}
 		headers[k] = value
 	}
+	if err := readHTTPRequestConfig(check, js.Get("config"), headers); err != nil {
+		return err
+	}
 	check.Config.Set("headers", headers)
 
+	return readHTTPAssertionsConfig(check, js.Get("config"))
+}
+
+// Reads the optional method, body and redirect policy of an HTTP check.
+// A JSON body is encoded and sent with an application/json Content-Type, unless headers already define one.
+func readHTTPRequestConfig(check *Check, config *simplejson.Json, headers map[string]string) error {
+	if js, ok := config.CheckGet("method"); ok {
+		method, err := js.String()
+		if err != nil {
+			return err
+		}
+		check.Config.Set("method", strings.ToUpper(method))
+	}
+
+	if js, ok := config.CheckGet("body"); ok {
+		if body, err := js.String(); err == nil {
+			check.Config.Set("body", body)
+		} else {
+			body, err := js.Encode()
+			if err != nil {
+				return err
+			}
+			hasContentType := false
+			for k := range headers {
+				if strings.EqualFold(k, "Content-Type") {
+					hasContentType = true
+				}
+			}
+			if !hasContentType {
+				headers["Content-Type"] = "application/json"
+			}
+			check.Config.Set("body", string(body))
+		}
+	}
+
+	if js, ok := config.CheckGet("followRedirects"); ok {
+		follow, err := js.Bool()
+		if err != nil {
+			return err
+		}
+		check.Config.Set("followRedirects", follow)
+	}
+
+	if js, ok := config.CheckGet("maxRedirects"); ok {
+		max, err := js.Int()
+		if err != nil {
+			return err
+		}
+		if max < 0 {
+			return fmt.Errorf("maxRedirects cannot be negative.")
+		}
+		check.Config.Set("maxRedirects", max)
+	}
+
+	return nil
+}
+
+// Reads the optional assertions of an HTTP check. Only defined assertions are set in the check's config.
+func readHTTPAssertionsConfig(check *Check, config *simplejson.Json) error {
+	if js, ok := config.CheckGet("statusCodes"); ok {
+		values, err := js.Array()
+		if err != nil || len(values) == 0 {
+			return fmt.Errorf("statusCodes should be a list of status code ranges.")
+		}
+		statusCodes := make([]string, 0, len(values))
+		for _, v := range values {
+			// Single status codes can be given as numbers
+			var r string
+			switch value := v.(type) {
+			case string:
+				r = value
+			case json.Number:
+				r = value.String()
+			default:
+				return fmt.Errorf("statusCodes can only accept strings and numbers.")
+			}
+			if _, _, err := parseStatusCodeRange(r); err != nil {
+				return err
+			}
+			statusCodes = append(statusCodes, r)
+		}
+		check.Config.Set("statusCodes", statusCodes)
+	}
+
+	for _, key := range []string{"bodyContains", "bodyNotContains", "bodyRegexp", "bodyNotRegexp"} {
+		js, ok := config.CheckGet(key)
+		if !ok {
+			continue
+		}
+		value, err := js.String()
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(key, "Regexp") {
+			if _, err := regexp.Compile(value); err != nil {
+				return err
+			}
+		}
+		check.Config.Set(key, value)
+	}
+
+	if js, ok := config.CheckGet("responseHeaders"); ok {
+		headers := make(map[string]string)
+		for k, v := range js.MustMap() {
+			value, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("Response headers can only accept string.")
+			}
+			headers[k] = value
+		}
+		check.Config.Set("responseHeaders", headers)
+	}
+
+	if js, ok := config.CheckGet("jsonPath"); ok {
+		assertions, err := js.Map()
+		if err != nil {
+			return err
+		}
+		for path := range assertions {
+			if _, err := parseJSONPath(path); err != nil {
+				return err
+			}
+		}
+		check.Config.Set("jsonPath", assertions)
+	}
+
 	return nil
 }
 
@@ -157,3 +404,108 @@ func readUDPConfig(check *Check, js *simplejson.Json) error {
 
 	return nil
 }
+
+func readTCPConfig(check *Check, js *simplejson.Json) error {
+	if host, err := js.Get("config").Get("host").String(); err != nil {
+		return err
+	} else {
+		check.Config.Set("host", host)
+	}
+	if port, err := js.Get("config").Get("port").Int(); err != nil {
+		return err
+	} else {
+		check.Config.Set("port", port)
+	}
+
+	// send, receive and receiveRegexp are optional
+	check.Config.Set("send", js.Get("config").Get("send").MustString())
+	check.Config.Set("receive", js.Get("config").Get("receive").MustString())
+
+	receiveRegexp := js.Get("config").Get("receiveRegexp").MustString()
+	if _, err := regexp.Compile(receiveRegexp); err != nil {
+		return err
+	}
+	check.Config.Set("receiveRegexp", receiveRegexp)
+
+	return nil
+}
+
+func readTLSConfig(check *Check, js *simplejson.Json) error {
+	if host, err := js.Get("config").Get("host").String(); err != nil {
+		return err
+	} else {
+		check.Config.Set("host", host)
+	}
+
+	// port defaults to 443, serverName to host and minValidDays to 0 (only expired certificates are down)
+	check.Config.Set("port", js.Get("config").Get("port").MustInt(443))
+	check.Config.Set("serverName", js.Get("config").Get("serverName").MustString())
+	check.Config.Set("minValidDays", js.Get("config").Get("minValidDays").MustInt())
+
+	// caFile is optional, chains are verified against the system's pool by default
+	if caFile := js.Get("config").Get("caFile").MustString(); caFile != "" {
+		if _, err := loadCertPool(caFile); err != nil {
+			return err
+		}
+		check.Config.Set("caFile", caFile)
+	}
+
+	return nil
+}
+
+func readDNSConfig(check *Check, js *simplejson.Json) error {
+	if nameserver, err := js.Get("config").Get("nameserver").String(); err != nil {
+		return err
+	} else {
+		if _, _, err := net.SplitHostPort(nameserver); err != nil {
+			nameserver = net.JoinHostPort(nameserver, "53")
+		}
+		check.Config.Set("nameserver", nameserver)
+	}
+	if name, err := js.Get("config").Get("name").String(); err != nil {
+		return err
+	} else {
+		check.Config.Set("name", name)
+	}
+
+	recordType := strings.ToUpper(js.Get("config").Get("recordType").MustString("A"))
+	if _, ok := dnsRecordTypes[recordType]; !ok {
+		return fmt.Errorf("Unknown DNS record type %s", recordType)
+	}
+	check.Config.Set("recordType", recordType)
+
+	expected := make([]string, 0)
+	for _, v := range js.Get("config").Get("expected").MustArray() {
+		value, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("Expected answers can only be strings.")
+		}
+		expected = append(expected, value)
+	}
+	check.Config.Set("expected", expected)
+
+	return nil
+}
+
+func readPingConfig(check *Check, js *simplejson.Json) error {
+	if host, err := js.Get("config").Get("host").String(); err != nil {
+		return err
+	} else {
+		check.Config.Set("host", host)
+	}
+
+	count := js.Get("config").Get("count").MustInt(3)
+	if count <= 0 {
+		return fmt.Errorf("Ping count should be greater than 0.")
+	}
+	check.Config.Set("count", count)
+
+	// maxLoss is a percentage, any loss is considered down by default
+	maxLoss := js.Get("config").Get("maxLoss").MustInt()
+	if maxLoss < 0 || maxLoss > 100 {
+		return fmt.Errorf("Ping maxLoss should be a percentage.")
+	}
+	check.Config.Set("maxLoss", maxLoss)
+
+	return nil
+}
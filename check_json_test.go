@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"strings"
 	//"github.com/davecgh/go-spew/spew"
 	"testing"
+	"time"
 )
 
 var testJsonHttpCheck = `
@@ -68,3 +70,151 @@ func TestCheckJSON(t *testing.T) {
 		t.Errorf("JSON() do not output correct representation of Check")
 	}
 }
+
+func TestNewTCPCheckFromJSON(t *testing.T) {
+	check, err := NewCheckFromJSON([]byte(`{"type": "tcp", "key": "redis", "interval": "10s", "alert": false, "alertDelay": "0s", "notifyFix": false, "config": {"host": "localhost", "port": 6379, "send": "PING\r\n", "receive": "+PONG"}}`))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if check.Type() != CheckTypeTCP {
+		t.Errorf("Type should be tcp")
+	}
+	if check.Config.GetInt("port") != 6379 {
+		t.Errorf("Port is wrong")
+	}
+	if check.Config.GetString("receive") != "+PONG" {
+		t.Errorf("Receive is wrong")
+	}
+
+	if _, err := NewCheckFromJSON([]byte(`{"type": "tcp", "key": "redis", "interval": "10s", "alert": false, "alertDelay": "0s", "notifyFix": false, "config": {"host": "localhost", "port": 6379, "receiveRegexp": "("}}`)); err == nil {
+		t.Errorf("An invalid receiveRegexp should return an error")
+	}
+}
+
+func TestNewHTTPCheckWithAssertionsFromJSON(t *testing.T) {
+	check, err := NewCheckFromJSON([]byte(`{"type": "http", "key": "api", "interval": "10s", "alert": false, "alertDelay": "0s", "notifyFix": false, "config": {"url": "http://localhost/", "statusCodes": [204, "3xx"], "bodyRegexp": "ok$", "jsonPath": {"$.items[0].id": 1}}}`))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	statusCodes := check.Config.Map()["statusCodes"].([]string)
+	if len(statusCodes) != 2 || statusCodes[0] != "204" || statusCodes[1] != "3xx" {
+		t.Errorf("statusCodes is wrong. Got %v", statusCodes)
+	}
+	if check.Config.GetString("bodyRegexp") != "ok$" {
+		t.Errorf("bodyRegexp is wrong")
+	}
+
+	if _, err := NewCheckFromJSON([]byte(`{"type": "http", "key": "api", "interval": "10s", "alert": false, "alertDelay": "0s", "notifyFix": false, "config": {"url": "http://localhost/", "statusCodes": ["2xx-3xx"]}}`)); err == nil {
+		t.Errorf("An invalid status code range should return an error")
+	}
+	if _, err := NewCheckFromJSON([]byte(`{"type": "http", "key": "api", "interval": "10s", "alert": false, "alertDelay": "0s", "notifyFix": false, "config": {"url": "http://localhost/", "statusCodes": "2xx"}}`)); err == nil {
+		t.Errorf("statusCodes which is not a list should return an error")
+	}
+}
+
+func TestNewHTTPCheckWithJSONBodyFromJSON(t *testing.T) {
+	check, err := NewCheckFromJSON([]byte(`{"type": "http", "key": "api", "interval": "10s", "alert": false, "alertDelay": "0s", "notifyFix": false, "config": {"url": "http://localhost/", "method": "post", "body": {"ping": true}, "followRedirects": false}}`))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if check.Config.GetString("method") != "POST" {
+		t.Errorf("method is wrong")
+	}
+	if check.Config.GetString("body") != `{"ping":true}` {
+		t.Errorf("body is wrong. Got %s", check.Config.GetString("body"))
+	}
+	if check.Config.GetMapStringString("headers")["Content-Type"] != "application/json" {
+		t.Errorf("Content-Type header should be set for JSON bodies")
+	}
+	if check.Config.Map()["followRedirects"] != false {
+		t.Errorf("followRedirects is wrong")
+	}
+}
+
+func TestCheckTimeoutJSON(t *testing.T) {
+	data := strings.Replace(testJsonHttpCheck, `"interval": "1m0s",`, `"interval": "1m0s", "timeout": "30s",`, 1)
+	check, err := NewCheckFromJSON([]byte(data))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if check.Timeout.Seconds() != 30 {
+		t.Errorf("Timeout should be equal to 30s.")
+	}
+
+	marshaled, _ := check.JSON()
+	check, err = NewCheckFromJSON(marshaled)
+	if err != nil || check.Timeout.Seconds() != 30 {
+		t.Errorf("Timeout should survive marshalling")
+	}
+}
+
+func TestCheckTagsJSON(t *testing.T) {
+	data := strings.Replace(testJsonHttpCheck, `"notifyFix": true,`, `"notifyFix": true, "tags": {"team": "web"},`, 1)
+	check, err := NewCheckFromJSON([]byte(data))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if check.Tags["team"] != "web" {
+		t.Errorf("Tags are wrong")
+	}
+
+	buffer := new(bytes.Buffer)
+	json.Compact(buffer, []byte(data))
+	marshaled, _ := check.JSON()
+	if string(marshaled) != buffer.String() {
+		t.Errorf("JSON() do not output tags correctly. Got %s", marshaled)
+	}
+}
+
+func TestCheckAlertersJSON(t *testing.T) {
+	data := strings.Replace(testJsonHttpCheck, `"notifyFix": true,`, `"notifyFix": true, "alerters": ["smtp-oncall", "chat"],`, 1)
+	check, err := NewCheckFromJSON([]byte(data))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if len(check.Alerters) != 2 || check.Alerters[0] != "smtp-oncall" || check.Alerters[1] != "chat" {
+		t.Errorf("Alerters are wrong. Got %v", check.Alerters)
+	}
+
+	buffer := new(bytes.Buffer)
+	json.Compact(buffer, []byte(data))
+	marshaled, _ := check.JSON()
+	if string(marshaled) != buffer.String() {
+		t.Errorf("JSON() do not output alerters correctly. Got %s", marshaled)
+	}
+
+	data = strings.Replace(testJsonHttpCheck, `"notifyFix": true,`, `"notifyFix": true, "alerters": [42],`, 1)
+	if _, err := NewCheckFromJSON([]byte(data)); err == nil {
+		t.Error("Alerters should only accept strings")
+	}
+}
+
+func TestCheckEscalationJSON(t *testing.T) {
+	data := strings.Replace(testJsonHttpCheck, `"notifyFix": true,`, `"notifyFix": true, "escalation": [{"after": "5m0s", "alerters": ["smtp-oncall"]}, {"after": "1m0s", "alerters": ["chat"]}],`, 1)
+	check, err := NewCheckFromJSON([]byte(data))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if len(check.Escalation) != 2 || check.Escalation[0].After != time.Minute || check.Escalation[1].Alerters[0] != "smtp-oncall" {
+		t.Errorf("Escalation should be sorted by delay. Got %v", check.Escalation)
+	}
+
+	marshaled, _ := check.JSON()
+	if !strings.Contains(string(marshaled), `"escalation":[{"after":"1m0s","alerters":["chat"]},{"after":"5m0s","alerters":["smtp-oncall"]}]`) {
+		t.Errorf("JSON() do not output escalation correctly. Got %s", marshaled)
+	}
+
+	for _, escalation := range []string{`{}`, `[{"alerters": ["chat"]}]`, `[{"after": "foo", "alerters": ["chat"]}]`, `[{"after": "1m"}]`} {
+		data = strings.Replace(testJsonHttpCheck, `"notifyFix": true,`, `"notifyFix": true, "escalation": `+escalation+`,`, 1)
+		if _, err := NewCheckFromJSON([]byte(data)); err == nil {
+			t.Errorf("Escalation %s should be rejected", escalation)
+		}
+	}
+}
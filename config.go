@@ -1,9 +1,14 @@
 package poller
 
+import (
+	"fmt"
+)
+
 // The Config struct holds and links together a CheckList, a Scheduler and a configuration Store.
 type Config struct {
 	scheduler Scheduler
 	store     Store
+	alerter   Alerter // alerter the checks' alerts are sent to, if known
 }
 
 // Instantiates a new Config with an empty CheckList
@@ -15,7 +20,39 @@ func (c *Config) Schedule() {
 	c.store.ScheduleAll(c.scheduler)
 }
 
+// SetAlerter defines the alerter receiving the checks' alerts. Checks added from then on are validated against it.
+func (c *Config) SetAlerter(alerter Alerter) {
+	c.alerter = alerter
+}
+
+// Validate returns an error if the check, or its escalation steps, reference alerters that the config's
+// alerter does not know.
+func (c *Config) Validate(check *Check) error {
+	names := append([]string{}, check.Alerters...)
+	for _, step := range check.Escalation {
+		names = append(names, step.Alerters...)
+	}
+	if c.alerter == nil || len(names) == 0 {
+		return nil
+	}
+
+	router, ok := c.alerter.(AlerterRouter)
+	if !ok {
+		return fmt.Errorf("Check %s references alerters but alerts cannot be routed by name", check.Key)
+	}
+	for _, name := range names {
+		if !router.HasAlerter(name) {
+			return fmt.Errorf("Check %s references unknown alerter %s", check.Key, name)
+		}
+	}
+
+	return nil
+}
+
 func (c *Config) Add(check *Check) error {
+	if err := c.Validate(check); err != nil {
+		return err
+	}
 	if err := c.store.Add(check); err != nil {
 		return err
 	}
@@ -25,3 +62,51 @@ func (c *Config) Add(check *Check) error {
 
 	return nil
 }
+
+// Acknowledge acknowledges the check's downtime, which stops its escalation. If the store is a StateStore,
+// the acknowledgement is persisted with the check's state. Returns false if the check is not down.
+func (c *Config) Acknowledge(check *Check) bool {
+	if !check.Acknowledge() {
+		return false
+	}
+	if s, ok := c.store.(StateStore); ok {
+		s.Log(NewEvent(check))
+	}
+
+	return true
+}
+
+// Get returns the check identified by key, or nil if there is no such check.
+func (c *Config) Get(key string) (*Check, error) {
+	return c.store.Get(key)
+}
+
+// All returns every check known by the store. An error is returned if the store is not a ListableStore.
+func (c *Config) All() ([]*Check, error) {
+	s, ok := c.store.(ListableStore)
+	if !ok {
+		return nil, fmt.Errorf("The store cannot list its checks")
+	}
+
+	return s.All()
+}
+
+// Update replaces an existing check and reschedules it.
+// The existing check keeps being polled if the new one is invalid or cannot be stored.
+func (c *Config) Update(check *Check) error {
+	if err := c.Validate(check); err != nil {
+		return err
+	}
+	if err := c.store.Add(check); err != nil {
+		return err
+	}
+	c.scheduler.Stop(check.Key)
+
+	return c.scheduler.Schedule(check)
+}
+
+// Remove unschedules the check identified by key and removes it from the store.
+func (c *Config) Remove(key string) error {
+	c.scheduler.Stop(key)
+	return c.store.Remove(key)
+}
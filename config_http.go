@@ -1,8 +1,10 @@
 package poller
 
 import (
+	"bytes"
 	"io/ioutil"
 	"net/http"
+	"strings"
 )
 
 type configHttpHandler struct {
@@ -10,35 +12,210 @@ type configHttpHandler struct {
 }
 
 // Create a handler function that is usable by http.Handle.
-// This handler will be able response to GET, POST and PUT requests.
-// * GET the list of checks as a JSON array
+// This handler will be able response to GET, POST, PUT and DELETE requests.
+// * GET /checks returns the list of checks as a JSON array
+// * GET /checks/{key} returns the check identified by key
 // * POST will create a new check and add it to the CheckList.
-// * After any of POST or PUT operation, the configuration is persisted to it's store.
+// * PUT /checks/{key} replaces the check identified by key and reschedules it.
+// * DELETE /checks/{key} unschedules the check and removes it.
+// * POST /checks/{key}/acknowledge acknowledges the check's downtime, which stops its escalation.
+// * After any of POST, PUT or DELETE operation, the configuration is persisted to it's store.
+// The handler can be mounted either on "/checks/" or on a prefix stripped path.
 func NewConfigHttpHandler(config *Config) http.Handler {
 	return &configHttpHandler{config}
 }
 
+// Suffix of the path acknowledging a check's downtime
+const acknowledgePathSuffix = "/acknowledge"
+
+// Returns the check key contained in the request path, or an empty string if the path targets the collection.
+// The path either starts with "/checks/" or is prefix stripped.
+func checkKeyFromPath(path string) string {
+	if path == "/checks" || strings.HasPrefix(path, "/checks/") {
+		path = strings.TrimPrefix(path, "/checks")
+	}
+
+	return strings.Trim(path, "/")
+}
+
 func (h *configHttpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.Method == "POST" {
-		data, err := ioutil.ReadAll(r.Body)
-		if err != nil {
-			// TODO: Log the error on the server
-			http.Error(w, err.Error(), 500)
+	key := checkKeyFromPath(r.URL.Path)
+
+	if strings.HasSuffix(key, acknowledgePathSuffix) {
+		if r.Method != "POST" {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, http.StatusText(405), 405)
 			return
 		}
-		defer r.Body.Close()
+		h.acknowledge(w, r, strings.TrimSuffix(key, acknowledgePathSuffix))
+		return
+	}
+
+	switch {
+	case r.Method == "GET" && key == "":
+		h.list(w, r)
+	case r.Method == "GET":
+		h.get(w, r, key)
+	case r.Method == "POST" && key == "":
+		h.create(w, r)
+	case r.Method == "PUT" && key != "":
+		h.update(w, r, key)
+	case r.Method == "DELETE" && key != "":
+		h.remove(w, r, key)
+	default:
+		if key == "" {
+			w.Header().Set("Allow", "GET, POST")
+		} else {
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+		}
+		http.Error(w, http.StatusText(405), 405)
+	}
+}
+
+func (h *configHttpHandler) list(w http.ResponseWriter, r *http.Request) {
+	checks, err := h.config.All()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
 
-		check, err := NewCheckFromJSON(data)
+	buffer := new(bytes.Buffer)
+	buffer.WriteString("[")
+	for i, check := range checks {
+		data, err := check.JSON()
 		if err != nil {
-			http.Error(w, err.Error(), 400)
-			return
-		}
-		if err := h.config.Add(check); err != nil {
 			http.Error(w, err.Error(), 500)
 			return
 		}
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		buffer.Write(data)
+	}
+	buffer.WriteString("]")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(buffer.Bytes())
+}
+
+func (h *configHttpHandler) get(w http.ResponseWriter, r *http.Request, key string) {
+	check, err := h.config.Get(key)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	if check == nil {
+		http.NotFound(w, r)
+		return
+	}
 
-		w.WriteHeader(201)
+	data, err := check.JSON()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
 		return
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func (h *configHttpHandler) create(w http.ResponseWriter, r *http.Request) {
+	check, ok := h.readCheckFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := h.config.Add(check); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.WriteHeader(201)
+}
+
+func (h *configHttpHandler) update(w http.ResponseWriter, r *http.Request, key string) {
+	existing, err := h.config.Get(key)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	if existing == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	check, ok := h.readCheckFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if check.Key != key {
+		http.Error(w, "Check key does not match the requested URL", 400)
+		return
+	}
+	if err := h.config.Update(check); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+func (h *configHttpHandler) remove(w http.ResponseWriter, r *http.Request, key string) {
+	existing, err := h.config.Get(key)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	if existing == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := h.config.Remove(key); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+func (h *configHttpHandler) acknowledge(w http.ResponseWriter, r *http.Request, key string) {
+	check, err := h.config.Get(key)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	if check == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if !h.config.Acknowledge(check) {
+		http.Error(w, "Check is not down", 409)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+// Reads a check from the request body and validates it. On failure, the error is written to w and false is returned.
+func (h *configHttpHandler) readCheckFromRequest(w http.ResponseWriter, r *http.Request) (*Check, bool) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		// TODO: Log the error on the server
+		http.Error(w, err.Error(), 500)
+		return nil, false
+	}
+	defer r.Body.Close()
+
+	check, err := NewCheckFromJSON(data)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return nil, false
+	}
+	if err := h.config.Validate(check); err != nil {
+		http.Error(w, err.Error(), 400)
+		return nil, false
+	}
+
+	return check, true
 }
@@ -1,11 +1,13 @@
 package poller
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestServeHTTPPost(t *testing.T) {
@@ -38,3 +40,283 @@ func TestServeHTTPPost(t *testing.T) {
 		t.Errorf("Check interval should be equal to 60s.")
 	}
 }
+
+func newTestConfigWithCheck(t *testing.T) *Config {
+	c := NewConfig(NewInMemoryStore(), NewSimpleScheduler())
+	check, err := NewCheckFromJSON([]byte(testJsonHttpCheck))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if err := c.Add(check); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	return c
+}
+
+func TestServeHTTPGetList(t *testing.T) {
+	c := newTestConfigWithCheck(t)
+
+	server := httptest.NewServer(NewConfigHttpHandler(c))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/checks")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if resp.StatusCode != 200 {
+		t.Errorf("Status code should be 200. Got %d\n", resp.StatusCode)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	var checks []map[string]interface{}
+	if err := json.Unmarshal(body, &checks); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if len(checks) != 1 {
+		t.Log("Response should contain 1 check")
+		t.FailNow()
+	}
+	if checks[0]["key"] != "connect_sensiolabs_com_api" {
+		t.Errorf("Check key is wrong. Got %v", checks[0]["key"])
+	}
+}
+
+func TestServeHTTPGet(t *testing.T) {
+	c := newTestConfigWithCheck(t)
+
+	server := httptest.NewServer(NewConfigHttpHandler(c))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/checks/connect_sensiolabs_com_api")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if resp.StatusCode != 200 {
+		t.Errorf("Status code should be 200. Got %d\n", resp.StatusCode)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if _, err := NewCheckFromJSON(body); err != nil {
+		t.Errorf("Response should be a valid check: %s", err)
+	}
+
+	resp, err = http.Get(server.URL + "/checks/unknown")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if resp.StatusCode != 404 {
+		t.Errorf("Status code should be 404. Got %d\n", resp.StatusCode)
+	}
+}
+
+func TestServeHTTPPut(t *testing.T) {
+	c := newTestConfigWithCheck(t)
+
+	server := httptest.NewServer(NewConfigHttpHandler(c))
+	defer server.Close()
+
+	body := strings.Replace(testJsonHttpCheck, `"interval": "1m0s"`, `"interval": "2m0s"`, 1)
+	r, err := http.NewRequest("PUT", server.URL+"/checks/connect_sensiolabs_com_api", strings.NewReader(body))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if resp.StatusCode != 204 {
+		t.Errorf("Status code should be 204. Got %d\n", resp.StatusCode)
+	}
+	check, _ := c.store.Get("connect_sensiolabs_com_api")
+	if check == nil {
+		t.Log("Store should contain check")
+		t.FailNow()
+	}
+	if check.Interval.Seconds() != 120 {
+		t.Errorf("Check interval should be equal to 120s.")
+	}
+
+	r, _ = http.NewRequest("PUT", server.URL+"/checks/another_key", strings.NewReader(body))
+	resp, err = http.DefaultClient.Do(r)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if resp.StatusCode != 404 {
+		t.Errorf("Status code should be 404. Got %d\n", resp.StatusCode)
+	}
+}
+
+func TestServeHTTPDelete(t *testing.T) {
+	c := newTestConfigWithCheck(t)
+
+	server := httptest.NewServer(NewConfigHttpHandler(c))
+	defer server.Close()
+
+	r, err := http.NewRequest("DELETE", server.URL+"/checks/connect_sensiolabs_com_api", nil)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if resp.StatusCode != 204 {
+		t.Errorf("Status code should be 204. Got %d\n", resp.StatusCode)
+	}
+	if l, _ := c.store.Len(); l != 0 {
+		t.Errorf("Store should be empty")
+	}
+}
+
+func TestServeHTTPAcknowledge(t *testing.T) {
+	c := newTestConfigWithCheck(t)
+
+	server := httptest.NewServer(NewConfigHttpHandler(c))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/checks/connect_sensiolabs_com_api/acknowledge", "", nil)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if resp.StatusCode != 409 {
+		t.Errorf("Status code should be 409 when the check is up. Got %d\n", resp.StatusCode)
+	}
+
+	check, _ := c.Get("connect_sensiolabs_com_api")
+	NewEvent(check).Down()
+	resp, _ = http.Post(server.URL+"/checks/connect_sensiolabs_com_api/acknowledge", "", nil)
+	if resp.StatusCode != 204 {
+		t.Errorf("Status code should be 204. Got %d\n", resp.StatusCode)
+	}
+	if !check.Acknowledged {
+		t.Error("Check should be acknowledged")
+	}
+
+	resp, _ = http.Post(server.URL+"/checks/foobar/acknowledge", "", nil)
+	if resp.StatusCode != 404 {
+		t.Errorf("Status code should be 404. Got %d\n", resp.StatusCode)
+	}
+	resp, _ = http.Get(server.URL + "/checks/connect_sensiolabs_com_api/acknowledge")
+	if resp.StatusCode != 405 || resp.Header.Get("Allow") != "POST" {
+		t.Errorf("Status code should be 405. Got %d\n", resp.StatusCode)
+	}
+}
+
+func TestServeHTTPUnknownAlerter(t *testing.T) {
+	c := NewConfig(NewInMemoryStore(), NewSimpleScheduler())
+	alerter, _ := NewNamedAlerter(map[string]Alerter{"chat": &failingTestAlerter{}})
+	c.SetAlerter(alerter)
+
+	server := httptest.NewServer(NewConfigHttpHandler(c))
+	defer server.Close()
+
+	data := strings.Replace(testJsonHttpCheck, `"notifyFix": true,`, `"notifyFix": true, "alerters": ["chta"],`, 1)
+	resp, err := http.Post(server.URL+"/checks", "application/json", strings.NewReader(data))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if resp.StatusCode != 400 {
+		t.Errorf("Status code should be 400 when a check references an unknown alerter. Got %d\n", resp.StatusCode)
+	}
+	if l, _ := c.store.Len(); l != 0 {
+		t.Errorf("Store should be empty")
+	}
+
+	data = strings.Replace(testJsonHttpCheck, `"notifyFix": true,`, `"notifyFix": true, "alerters": ["chat"],`, 1)
+	resp, _ = http.Post(server.URL+"/checks", "application/json", strings.NewReader(data))
+	if resp.StatusCode != 201 {
+		t.Errorf("Status code should be 201. Got %d\n", resp.StatusCode)
+	}
+
+	// Alerts cannot be routed by name through a single alerter
+	c.SetAlerter(&failingTestAlerter{})
+	check, _ := NewCheckFromJSON([]byte(data))
+	if err := c.Add(check); err == nil {
+		t.Error("Add() should reject alerters which cannot be routed")
+	}
+
+	// as well as escalation policies
+	check, _ = NewCheckFromJSON([]byte(testJsonHttpCheck))
+	check.Escalation = []EscalationStep{{After: time.Minute, Alerters: []string{"chat"}}}
+	if err := c.Add(check); err == nil {
+		t.Error("Add() should reject escalation policies which cannot be routed")
+	}
+	c.SetAlerter(alerter)
+	check.Escalation = append(check.Escalation, EscalationStep{After: time.Hour, Alerters: []string{"pager"}})
+	if err := c.Add(check); err == nil {
+		t.Error("Add() should reject escalation steps referencing unknown alerters")
+	}
+}
+
+type stopRecordingTestScheduler struct {
+	Scheduler
+	stopped []string
+}
+
+func (s *stopRecordingTestScheduler) Stop(key string) {
+	s.stopped = append(s.stopped, key)
+	s.Scheduler.Stop(key)
+}
+
+func TestConfigUpdateInvalidCheck(t *testing.T) {
+	scheduler := &stopRecordingTestScheduler{Scheduler: NewSimpleScheduler()}
+	c := NewConfig(NewInMemoryStore(), scheduler)
+	alerter, _ := NewNamedAlerter(map[string]Alerter{"chat": &failingTestAlerter{}})
+	c.SetAlerter(alerter)
+	check, _ := NewCheckFromJSON([]byte(testJsonHttpCheck))
+	if err := c.Add(check); err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	invalid, _ := NewCheckFromJSON([]byte(testJsonHttpCheck))
+	invalid.Alerters = []string{"chta"}
+	if err := c.Update(invalid); err == nil {
+		t.Error("Update() should reject checks referencing unknown alerters")
+	}
+	if len(scheduler.stopped) != 0 {
+		t.Error("The existing check should still be scheduled")
+	}
+	if stored, _ := c.Get(check.Key); stored != check {
+		t.Error("The existing check should still be stored")
+	}
+
+	updated, _ := NewCheckFromJSON([]byte(testJsonHttpCheck))
+	if err := c.Update(updated); err != nil {
+		t.Error(err)
+	}
+	if len(scheduler.stopped) != 1 {
+		t.Error("The existing check should have been unscheduled")
+	}
+	if stored, _ := c.Get(check.Key); stored != updated {
+		t.Error("The check should have been replaced")
+	}
+	scheduler.StopAll()
+}
+
+func TestCheckKeyFromPath(t *testing.T) {
+	for path, key := range map[string]string{
+		"/checks":           "",
+		"/checks/":          "",
+		"/checks/foo":       "foo",
+		"/checks/foo/":      "foo",
+		"/foo":              "foo",
+		"/checksfoo":        "checksfoo",
+		"/checks/checksfoo": "checksfoo"} {
+		if got := checkKeyFromPath(path); got != key {
+			t.Errorf("Key of %s should be %q. Got %q", path, key, got)
+		}
+	}
+}
@@ -13,10 +13,13 @@ type Event struct {
 	up         bool          // true if service is up
 	Alert      bool          // true if backend should raise an alert
 	NotifyFix  bool          // true if backend should notify of service being up again
+	Alerters   []string      // names of the alerters to notify, when the check's escalation restricts them
+
+	Details map[string]interface{} // probe specific details, if any
 }
 
 func NewEvent(check *Check) *Event {
-	return &Event{Time: time.Now(), Check: check}
+	return &Event{Time: time.Now(), Check: check, Details: make(map[string]interface{})}
 }
 
 func (e *Event) IsUp() bool {
@@ -35,7 +38,15 @@ func (e *Event) Up() {
 		e.Check.UpSince = e.Time
 		e.Check.WasDownFor = e.Time.Sub(e.Check.DownSince)
 		e.Check.DownSince = time.Time{}
-		e.NotifyFix = e.Check.NotifyFix
+
+		// Only notify of the fix if the downtime was alerted, to the alerters of every notified escalation step
+		e.NotifyFix = e.Check.NotifyFix && e.Check.Alerted
+		e.Check.Alerted = false
+		for _, step := range e.Check.Escalation[:e.Check.Escalated] {
+			e.addAlerters(step.Alerters)
+		}
+		e.Check.Escalated = 0
+		e.Check.Acknowledged = false
 	}
 }
 
@@ -51,11 +62,63 @@ func (e *Event) Down() {
 		e.Check.DownSince = e.Time
 		e.Check.WasUpFor = e.Time.Sub(e.Check.UpSince)
 		e.Check.UpSince = time.Time{}
+	}
+
+	if len(e.Check.Escalation) > 0 {
+		e.escalate()
+		return
+	}
+
+	// Is it time we alert backend?
+	if e.Check.ShouldAlert() {
+		e.Alert = true
+		e.Check.Alerted = true
+	}
+}
+
+// Marks the event as down because the check could not be polled, ie: the connection was refused.
+// err is stored in the event's details as "error".
+func (e *Event) fail(err error) {
+	details := make(map[string]interface{}, len(e.Details)+1)
+	for k, v := range e.Details {
+		details[k] = v
+	}
+	details["error"] = err.Error()
+	e.Details = details
+
+	e.Down()
+}
+
+// Alerts the alerters of the escalation steps which became due, unless the downtime is acknowledged.
+func (e *Event) escalate() {
+	if !e.Check.Alert || e.Check.Acknowledged {
+		return
+	}
 
-		// Is it time we alert backend?
-		if !e.Check.ShouldAlert() {
-			e.Alert = true
-			e.Check.Alerted = true
+	due := e.Check.dueEscalationSteps(e.Time)
+	if due <= e.Check.Escalated {
+		return
+	}
+	for _, step := range e.Check.Escalation[e.Check.Escalated:due] {
+		e.addAlerters(step.Alerters)
+	}
+	e.Check.Escalated = due
+	e.Alert = true
+	e.Check.Alerted = true
+}
+
+// Adds alerters to the event's alerters, skipping those it already has
+func (e *Event) addAlerters(alerters []string) {
+	for _, alerter := range alerters {
+		found := false
+		for _, existing := range e.Alerters {
+			if existing == alerter {
+				found = true
+				break
+			}
+		}
+		if !found {
+			e.Alerters = append(e.Alerters, alerter)
 		}
 	}
 }
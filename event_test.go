@@ -0,0 +1,108 @@
+package poller
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventAlertAndNotifyFix(t *testing.T) {
+	c, _ := NewCheck("foo", "10s", true, "0s", true, make(map[string]interface{}))
+
+	e := NewEvent(c)
+	e.Up()
+	if e.Alert || e.NotifyFix {
+		t.Error("An up check should neither alert nor notify a fix")
+	}
+
+	e = NewEvent(c)
+	e.Time = e.Time.Add(-time.Second)
+	e.Down()
+	if !e.Alert || !c.Alerted {
+		t.Error("A down check should alert")
+	}
+
+	e = NewEvent(c)
+	e.Down()
+	if e.Alert {
+		t.Error("A down check should only alert once")
+	}
+
+	e = NewEvent(c)
+	e.Up()
+	if !e.NotifyFix {
+		t.Error("A check back up should notify the fix")
+	}
+	if c.Alerted {
+		t.Error("Alerted should be reset once the check is back up")
+	}
+
+	c.AlertDelay = time.Hour
+	e = NewEvent(c)
+	e.Down()
+	if e.Alert {
+		t.Error("A down check should not alert before its alert delay")
+	}
+	e = NewEvent(c)
+	e.Up()
+	if e.NotifyFix {
+		t.Error("A fix should not be notified if the downtime was not alerted")
+	}
+}
+
+func TestEventEscalation(t *testing.T) {
+	c, _ := NewCheck("foo", "10s", true, "0s", true, make(map[string]interface{}))
+	c.Escalation = []EscalationStep{
+		{After: time.Minute, Alerters: []string{"chat"}},
+		{After: 5 * time.Minute, Alerters: []string{"email"}},
+		{After: 15 * time.Minute, Alerters: []string{"chat", "pager"}}}
+
+	start := time.Now()
+	down := func(after time.Duration) *Event {
+		e := NewEvent(c)
+		e.Time = start.Add(after)
+		e.Down()
+		return e
+	}
+
+	if e := down(0); e.Alert {
+		t.Error("No escalation step is due yet")
+	}
+	if e := down(time.Minute); !e.Alert || len(e.Alerters) != 1 || e.Alerters[0] != "chat" {
+		t.Errorf("Chat should be alerted after 1m. Got %v", e.Alerters)
+	}
+	if e := down(2 * time.Minute); e.Alert {
+		t.Error("A step should only be alerted once")
+	}
+	// Steps which became due together are alerted together
+	if e := down(20 * time.Minute); !e.Alert || len(e.Alerters) != 3 {
+		t.Errorf("Email and pager should be alerted after 15m. Got %v", e.Alerters)
+	}
+
+	e := NewEvent(c)
+	e.Up()
+	if !e.NotifyFix || len(e.Alerters) != 3 {
+		t.Errorf("The fix should be notified to every escalated alerter. Got %v", e.Alerters)
+	}
+	if c.Escalated != 0 || c.Alerted {
+		t.Error("Escalation should be reset once the check is back up")
+	}
+
+	// An acknowledged downtime is not escalated any further
+	start = time.Now()
+	down(0)
+	down(time.Minute)
+	if !c.Acknowledge() {
+		t.Error("A down check should be acknowledged")
+	}
+	if e := down(20 * time.Minute); e.Alert {
+		t.Error("An acknowledged downtime should not be escalated")
+	}
+	e = NewEvent(c)
+	e.Up()
+	if len(e.Alerters) != 1 || c.Acknowledged {
+		t.Errorf("Only chat should be notified of the fix and the acknowledgement reset. Got %v", e.Alerters)
+	}
+	if c.Acknowledge() {
+		t.Error("An up check should not be acknowledged")
+	}
+}
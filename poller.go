@@ -1,10 +1,23 @@
 package poller
 
+import (
+	"log"
+)
+
 // An Alerter raises an alert based on the event it received.
 // An alert is a communication to a system or a user with the information about current's and past check's states.
+// Alerters receive down events when Event.Alert is true, and up events when Event.NotifyFix is true.
 // For concrete implementation, see the "github.com/marcw/poller/alert" package.
+// Alert returns an error if the alert could not be delivered.
 type Alerter interface {
-	Alert(event *Event)
+	Alert(event *Event) error
+}
+
+// An AlerterRouter is an Alerter which dispatches alerts to the alerters it knows by name,
+// as referenced by the checks' Alerters.
+type AlerterRouter interface {
+	Alerter
+	HasAlerter(name string) bool
 }
 
 // A backend log checks event.
@@ -33,11 +46,24 @@ type Store interface {
 	ScheduleAll(Scheduler) error
 }
 
+// A ListableStore is a Store which can list every check it holds.
+type ListableStore interface {
+	Store
+	All() ([]*Check, error)
+}
+
+// A StateStore is a Store which also persists the runtime state of its checks.
+// It receives events as a Backend and records the state of their check.
+type StateStore interface {
+	Store
+	Backend
+}
+
 type directPoller struct {
 }
 
 // NewDirectPoller() returns a "no-frills" Poller instance.
-// It waits for the next scheduled check, poll it, log it and if alerting is needed, pass it through the alerter.
+// It waits for the next scheduled check, poll it, log it and if alerting or notifying a fix is needed, pass it through the alerter.
 func NewDirectPoller() Poller {
 	return &directPoller{}
 }
@@ -48,10 +74,16 @@ func (dp *directPoller) Run(scheduler Scheduler, backend Backend, probe Probe, a
 	}
 }
 
-func (db *directPoller) poll(check *Check, backend Backend, probe Probe, alerter Alerter) {
+func (dp *directPoller) poll(check *Check, backend Backend, probe Probe, alerter Alerter) {
 	event := probe.Test(check)
 	go backend.Log(event)
-	if event.Check.ShouldAlert() {
-		go alerter.Alert(event)
+	if event.Alert || event.NotifyFix {
+		go dp.alert(event, alerter)
+	}
+}
+
+func (dp *directPoller) alert(event *Event, alerter Alerter) {
+	if err := alerter.Alert(event); err != nil {
+		log.Println(err)
 	}
 }
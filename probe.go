@@ -0,0 +1,90 @@
+package poller
+
+import (
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Outcome of a poll. Probes fill it in the goroutine polling the check, and it is only applied to the event
+// if that goroutine returns before the timeout. A late reply thus never alters the event of a timed out poll.
+type probeResult struct {
+	up         bool
+	err        error                  // error which prevented the check from being polled, if any
+	statusCode int                    // http status code, if any
+	details    map[string]interface{} // probe specific details, if any
+}
+
+// Marks e and its check up or down according to the result
+func (r probeResult) apply(e *Event) {
+	e.StatusCode = r.statusCode
+	if r.details != nil {
+		e.Details = r.details
+	}
+
+	switch {
+	case r.err != nil:
+		e.fail(r.err)
+	case r.up:
+		e.Up()
+	default:
+		e.Down()
+	}
+}
+
+// Returns the event of polling c with poll, which is run in its own goroutine and receives the event's time.
+// The check is down if poll does not return within timeout.
+func testWithTimeout(c *Check, timeout time.Duration, poll func(now time.Time) probeResult) *Event {
+	event := NewEvent(c)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	// Buffered so that a late poll does not block once the timeout won
+	ch := make(chan probeResult, 1)
+
+	start := time.Now().UnixNano()
+	go func(now time.Time) {
+		ch <- poll(now)
+	}(event.Time)
+
+	select {
+	case <-timer.C:
+		end := time.Now().UnixNano()
+		event.Duration = time.Duration(end - start)
+		event.Down()
+
+	case result := <-ch:
+		end := time.Now().UnixNano()
+		event.Duration = time.Duration(end - start)
+		result.apply(event)
+	}
+
+	return event
+}
+
+// A regexpCache compiles the regular expressions of checks once, as checks are polled again and again.
+// Its zero value is ready to use.
+type regexpCache struct {
+	regexps map[string]*regexp.Regexp // compiled expressions, by expression
+	mu      sync.Mutex
+}
+
+// Returns the compiled expr
+func (rc *regexpCache) compile(expr string) (*regexp.Regexp, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if re, ok := rc.regexps[expr]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	if rc.regexps == nil {
+		rc.regexps = make(map[string]*regexp.Regexp)
+	}
+	rc.regexps[expr] = re
+
+	return re, nil
+}
@@ -0,0 +1,152 @@
+package poller
+
+import (
+	"fmt"
+	"golang.org/x/net/dns/dnsmessage"
+	"math/rand"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+var dnsRecordTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"MX":    dnsmessage.TypeMX,
+	"TXT":   dnsmessage.TypeTXT}
+
+type dnsProbe struct {
+	Timeout time.Duration
+}
+
+// Instantiates a Probe which queries the check's "nameserver" for the "recordType" records of "name".
+// The check is up if the server answers with a NOERROR rcode and at least one record.
+// If "expected" is defined, the answers must match it, regardless of their order.
+// The event's details hold the "rcode", the query "latency" and the "answers".
+// MX answers are the exchange host names and TXT answers the concatenation of their strings.
+func NewDnsProbe(timeout time.Duration) Probe {
+	return &dnsProbe{timeout}
+}
+
+func (p *dnsProbe) Test(c *Check) *Event {
+	timeout := c.TimeoutOr(p.Timeout)
+
+	return testWithTimeout(c, timeout, func(now time.Time) probeResult {
+		recordType, ok := dnsRecordTypes[c.Config.GetString("recordType")]
+		if !ok {
+			return probeResult{err: fmt.Errorf("Unknown DNS record type %s", c.Config.GetString("recordType"))}
+		}
+		name, err := dnsmessage.NewName(dnsFQDN(c.Config.GetString("name")))
+		if err != nil {
+			return probeResult{err: err}
+		}
+
+		id := uint16(rand.Intn(1 << 16))
+		query := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+			Questions: []dnsmessage.Question{{Name: name, Type: recordType, Class: dnsmessage.ClassINET}}}
+		packed, err := query.Pack()
+		if err != nil {
+			return probeResult{err: err}
+		}
+
+		conn, err := net.DialTimeout("udp", c.Config.GetString("nameserver"), timeout)
+		if err != nil {
+			return probeResult{err: err}
+		}
+		conn.SetDeadline(time.Now().Add(timeout))
+		defer conn.Close()
+
+		queried := time.Now()
+		if _, err := conn.Write(packed); err != nil {
+			return probeResult{err: err}
+		}
+
+		var reply dnsmessage.Message
+		buf := make([]byte, 4096)
+		for {
+			count, err := conn.Read(buf)
+			if err != nil {
+				return probeResult{err: err}
+			}
+			// Ignore replies which do not belong to our query
+			if err := reply.Unpack(buf[:count]); err == nil && reply.Header.ID == id {
+				break
+			}
+		}
+
+		details := make(map[string]interface{})
+		details["latency"] = time.Since(queried)
+		details["rcode"] = reply.Header.RCode.String()
+
+		answers := make([]string, 0, len(reply.Answers))
+		for _, answer := range reply.Answers {
+			if answer.Header.Type != recordType {
+				continue
+			}
+			answers = append(answers, dnsAnswerString(answer.Body))
+		}
+		details["answers"] = answers
+
+		if reply.Header.RCode != dnsmessage.RCodeSuccess || len(answers) == 0 {
+			return probeResult{details: details}
+		}
+
+		if expected, ok := c.Config.Map()["expected"].([]string); ok && len(expected) > 0 && !dnsAnswersMatch(answers, expected) {
+			return probeResult{details: details}
+		}
+
+		return probeResult{up: true, details: details}
+	})
+}
+
+func dnsFQDN(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+func dnsAnswerString(body dnsmessage.ResourceBody) string {
+	switch b := body.(type) {
+	case *dnsmessage.AResource:
+		return net.IP(b.A[:]).String()
+	case *dnsmessage.AAAAResource:
+		return net.IP(b.AAAA[:]).String()
+	case *dnsmessage.CNAMEResource:
+		return strings.TrimSuffix(b.CNAME.String(), ".")
+	case *dnsmessage.MXResource:
+		return strings.TrimSuffix(b.MX.String(), ".")
+	case *dnsmessage.TXTResource:
+		return strings.Join(b.TXT, "")
+	}
+
+	return fmt.Sprint(body)
+}
+
+// Returns true if answers and expected hold the same records, regardless of their order and case.
+func dnsAnswersMatch(answers, expected []string) bool {
+	if len(answers) != len(expected) {
+		return false
+	}
+
+	normalize := func(records []string) []string {
+		normalized := make([]string, len(records))
+		for i, v := range records {
+			normalized[i] = strings.ToLower(strings.TrimSuffix(v, "."))
+		}
+		sort.Strings(normalized)
+		return normalized
+	}
+
+	a, b := normalize(answers), normalize(expected)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,103 @@
+package poller
+
+import (
+	"golang.org/x/net/dns/dnsmessage"
+	"net"
+	"testing"
+	"time"
+)
+
+// Answers every A query with 127.0.0.1 and 127.0.0.2, and every other query with NXDOMAIN
+func newTestDNSServer(t *testing.T) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			count, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if err := query.Unpack(buf[:count]); err != nil {
+				continue
+			}
+			reply := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: query.Header.ID, Response: true},
+				Questions: query.Questions}
+			question := query.Questions[0]
+			if question.Type == dnsmessage.TypeA {
+				for _, ip := range [][4]byte{{127, 0, 0, 1}, {127, 0, 0, 2}} {
+					reply.Answers = append(reply.Answers, dnsmessage.Resource{
+						Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET},
+						Body:   &dnsmessage.AResource{A: ip}})
+				}
+			} else {
+				reply.Header.RCode = dnsmessage.RCodeNameError
+			}
+			packed, _ := reply.Pack()
+			conn.WriteTo(packed, addr)
+		}
+	}()
+
+	return conn
+}
+
+func newTestDNSCheck(server net.PacketConn, recordType string, expected []string) *Check {
+	c, _ := NewCheck("foobar", "10s", false, "", false, make(map[string]interface{}))
+	c.Config.Set("nameserver", server.LocalAddr().String())
+	c.Config.Set("name", "example.org")
+	c.Config.Set("recordType", recordType)
+	c.Config.Set("expected", expected)
+
+	return c
+}
+
+func TestDNSSuccessfullTest(t *testing.T) {
+	server := newTestDNSServer(t)
+	defer server.Close()
+
+	probe := NewDnsProbe(10 * time.Second)
+
+	event := probe.Test(newTestDNSCheck(server, "A", []string{}))
+	if event.IsUp() != true {
+		t.Error("IsUp() should be true")
+	}
+	if event.Details["rcode"] != "RCodeSuccess" {
+		t.Errorf("rcode should be RCodeSuccess. Got %v", event.Details["rcode"])
+	}
+	if _, ok := event.Details["latency"].(time.Duration); !ok {
+		t.Error("latency should be set")
+	}
+
+	event = probe.Test(newTestDNSCheck(server, "A", []string{"127.0.0.2", "127.0.0.1"}))
+	if event.IsUp() != true {
+		t.Error("IsUp() should be true when answers match the expected ones")
+	}
+}
+
+func TestDNSFailedTest(t *testing.T) {
+	server := newTestDNSServer(t)
+	defer server.Close()
+
+	probe := NewDnsProbe(10 * time.Second)
+
+	event := probe.Test(newTestDNSCheck(server, "A", []string{"127.0.0.1"}))
+	if event.IsUp() != false {
+		t.Error("IsUp() should be false when answers do not match the expected ones")
+	}
+
+	event = probe.Test(newTestDNSCheck(server, "MX", []string{}))
+	if event.IsUp() != false {
+		t.Error("IsUp() should be false")
+	}
+	if event.Details["rcode"] != "RCodeNameError" {
+		t.Errorf("rcode should be RCodeNameError. Got %v", event.Details["rcode"])
+	}
+	if event.Check.DownSince.IsZero() {
+		t.Error("DownSince should be set")
+	}
+}
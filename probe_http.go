@@ -1,28 +1,60 @@
 package poller
 
 import (
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
 	"time"
 )
 
+// Maximum number of bytes read from a response's body to run assertions against
+const maxHTTPBodySize = 1 << 20
+
+// Maximum number of redirects followed when the check does not define "maxRedirects"
+const defaultHTTPMaxRedirects = 10
+
 type httpProbe struct {
 	UserAgent string
 	Timeout   time.Duration
+
+	regexps regexpCache // compiled "bodyRegexp" and "bodyNotRegexp"
 }
 
+// Instantiates a Probe which sends a request to the check's "url".
+// The request's "method" (defaults to GET) and "body" are defined in the check's config.
+// Redirects are followed, up to "maxRedirects", unless "followRedirects" is false. When redirects are not
+// followed, assertions are run against the redirect response itself.
+// By default, the check is up if the response's status code is 200. The check's config can instead define
+// accepted "statusCodes" ranges ("200", "2xx" or "200-399"), required "responseHeaders", a "bodyContains" or
+// "bodyRegexp" the body should match, a "bodyNotContains" or "bodyNotRegexp" it should not, and "jsonPath"
+// assertions on the body ({"$.status": "ok"}). The first failed assertion is stored in the event's details
+// as "failedAssertion". Invalid assertions mark the check down, their error being stored as "error".
 func NewHttpProbe(ua string, timeout time.Duration) Probe {
 	return &httpProbe{UserAgent: ua, Timeout: timeout}
 }
 
 func (p *httpProbe) Test(c *Check) *Event {
-	event := NewEvent(c)
-	timer := time.NewTimer(p.Timeout)
-	ch := make(chan *Event, 1)
-
-	start := time.Now().UnixNano()
-	go func(e *Event, eventCh chan<- *Event) {
-		client := &http.Client{Jar: nil}
-		req, err := http.NewRequest("GET", c.Config.GetString("url"), nil)
+	timeout := c.TimeoutOr(p.Timeout)
+
+	return testWithTimeout(c, timeout, func(now time.Time) probeResult {
+		// The client's timeout stops the request, and the reading of its body, once the check timed out
+		client := &http.Client{Jar: nil, CheckRedirect: httpRedirectPolicy(c), Timeout: timeout}
+
+		method := c.Config.GetString("method")
+		if method == "" {
+			method = "GET"
+		}
+		var body io.Reader
+		if b := c.Config.GetString("body"); b != "" {
+			body = strings.NewReader(b)
+		}
+
+		req, err := http.NewRequest(method, c.Config.GetString("url"), body)
+		if err != nil {
+			return probeResult{err: err}
+		}
 		var header = http.Header{}
 
 		for k, v := range c.Config.GetMapStringString("headers") {
@@ -34,32 +66,48 @@ func (p *httpProbe) Test(c *Check) *Event {
 
 		resp, err := client.Do(req)
 		if err != nil {
-			eventCh <- e
-			return
+			return probeResult{err: err}
 		}
 		defer resp.Body.Close()
 
-		e.StatusCode = resp.StatusCode
-		if e.StatusCode == 200 {
-			e.Up()
-		} else {
-			e.Down()
+		var respBody []byte
+		if hasHTTPBodyAssertions(c) {
+			if respBody, err = ioutil.ReadAll(io.LimitReader(resp.Body, maxHTTPBodySize)); err != nil {
+				return probeResult{err: err, statusCode: resp.StatusCode}
+			}
 		}
 
-		eventCh <- e
-	}(event, ch)
+		failed, err := assertHTTPResponse(c, resp, respBody, &p.regexps)
+		if err != nil {
+			return probeResult{err: err, statusCode: resp.StatusCode}
+		}
+		if failed != "" {
+			return probeResult{statusCode: resp.StatusCode, details: map[string]interface{}{"failedAssertion": failed}}
+		}
 
-	select {
-	case <-timer.C:
-		end := time.Now().UnixNano()
-		event.Duration = time.Duration(end - start)
-		event.Down()
+		return probeResult{up: true, statusCode: resp.StatusCode}
+	})
+}
 
-		return event
+// Returns the CheckRedirect function of the http.Client used to poll the check
+func httpRedirectPolicy(c *Check) func(*http.Request, []*http.Request) error {
+	config := c.Config.Map()
+	follow, ok := config["followRedirects"].(bool)
+	if !ok {
+		follow = true
+	}
+	max, ok := config["maxRedirects"].(int)
+	if !ok {
+		max = defaultHTTPMaxRedirects
+	}
 
-	case e := <-ch:
-		end := time.Now().UnixNano()
-		event.Duration = time.Duration(end - start)
-		return e
+	return func(req *http.Request, via []*http.Request) error {
+		if !follow {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= max {
+			return fmt.Errorf("Stopped after %d redirects", max)
+		}
+		return nil
 	}
 }
@@ -0,0 +1,181 @@
+package poller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/bitly/go-simplejson"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Parses an accepted status code range. It can be a single code ("204"), a class ("2xx") or an inclusive range ("200-299").
+func parseStatusCodeRange(r string) (min, max int, err error) {
+	r = strings.TrimSpace(r)
+	if len(r) == 3 && strings.HasSuffix(strings.ToLower(r), "xx") {
+		class, err := strconv.Atoi(r[:1])
+		if err != nil {
+			return 0, 0, fmt.Errorf("Invalid status code range %s", r)
+		}
+		return class * 100, class*100 + 99, nil
+	}
+
+	bounds := strings.SplitN(r, "-", 2)
+	if min, err = strconv.Atoi(strings.TrimSpace(bounds[0])); err != nil {
+		return 0, 0, fmt.Errorf("Invalid status code range %s", r)
+	}
+	max = min
+	if len(bounds) == 2 {
+		if max, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil || max < min {
+			return 0, 0, fmt.Errorf("Invalid status code range %s", r)
+		}
+	}
+
+	return min, max, nil
+}
+
+// Splits a JSONPath expression such as "$.data.items[0].name" into its keys and array indexes.
+// Only the dot notation and array indexes are supported.
+func parseJSONPath(path string) ([]interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("JSON path %s should start with $", path)
+	}
+
+	segments := make([]interface{}, 0)
+	for _, part := range strings.Split(path[1:], ".") {
+		if part == "" {
+			continue
+		}
+		name := part
+		if i := strings.Index(part, "["); i >= 0 {
+			name = part[:i]
+		}
+		if name != "" {
+			segments = append(segments, name)
+		}
+		for rest := part[len(name):]; rest != ""; {
+			end := strings.Index(rest, "]")
+			if !strings.HasPrefix(rest, "[") || end < 0 {
+				return nil, fmt.Errorf("Invalid JSON path %s", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("Invalid JSON path %s", path)
+			}
+			segments = append(segments, index)
+			rest = rest[end+1:]
+		}
+	}
+
+	return segments, nil
+}
+
+// Checks a response against the assertions defined in the check's config, compiling its regular expressions
+// with regexps. Returns a description of the first failed assertion, or an empty string if all of them passed.
+// An error is returned if the assertions themselves are invalid.
+func assertHTTPResponse(c *Check, resp *http.Response, body []byte, regexps *regexpCache) (string, error) {
+	config := c.Config.Map()
+
+	statusCodes := []string{"200"}
+	if v, ok := config["statusCodes"]; ok {
+		if statusCodes, ok = v.([]string); !ok || len(statusCodes) == 0 {
+			return "", fmt.Errorf("statusCodes should be a list of status code ranges")
+		}
+	}
+	accepted := false
+	for _, r := range statusCodes {
+		min, max, err := parseStatusCodeRange(r)
+		if err != nil {
+			return "", err
+		}
+		if resp.StatusCode >= min && resp.StatusCode <= max {
+			accepted = true
+			break
+		}
+	}
+	if !accepted {
+		return fmt.Sprintf("status code %d is not accepted", resp.StatusCode), nil
+	}
+
+	for name, value := range c.Config.GetMapStringString("responseHeaders") {
+		if _, ok := resp.Header[http.CanonicalHeaderKey(name)]; !ok {
+			return fmt.Sprintf("header %s is missing", name), nil
+		}
+		if value != "" && resp.Header.Get(name) != value {
+			return fmt.Sprintf("header %s is %q instead of %q", name, resp.Header.Get(name), value), nil
+		}
+	}
+
+	if contains := c.Config.GetString("bodyContains"); contains != "" && !bytes.Contains(body, []byte(contains)) {
+		return fmt.Sprintf("body does not contain %q", contains), nil
+	}
+	if forbidden := c.Config.GetString("bodyNotContains"); forbidden != "" && bytes.Contains(body, []byte(forbidden)) {
+		return fmt.Sprintf("body contains %q", forbidden), nil
+	}
+	if expr := c.Config.GetString("bodyRegexp"); expr != "" {
+		re, err := regexps.compile(expr)
+		if err != nil {
+			return "", err
+		}
+		if !re.Match(body) {
+			return fmt.Sprintf("body does not match %s", expr), nil
+		}
+	}
+	if expr := c.Config.GetString("bodyNotRegexp"); expr != "" {
+		re, err := regexps.compile(expr)
+		if err != nil {
+			return "", err
+		}
+		if re.Match(body) {
+			return fmt.Sprintf("body matches %s", expr), nil
+		}
+	}
+
+	if assertions, ok := config["jsonPath"].(map[string]interface{}); ok && len(assertions) > 0 {
+		js, err := simplejson.NewJson(body)
+		if err != nil {
+			return "body is not valid JSON", nil
+		}
+		// Paths are asserted in order, so that the same failed assertion is reported from one poll to the next
+		paths := make([]string, 0, len(assertions))
+		for path := range assertions {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			segments, err := parseJSONPath(path)
+			if err != nil {
+				return "", err
+			}
+			value := js
+			for _, segment := range segments {
+				if index, ok := segment.(int); ok {
+					value = value.GetIndex(index)
+				} else {
+					value = value.Get(segment.(string))
+				}
+			}
+			got, _ := json.Marshal(value.Interface())
+			want, _ := json.Marshal(assertions[path])
+			if !bytes.Equal(got, want) {
+				return fmt.Sprintf("%s is %s instead of %s", path, got, want), nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// Returns true if the check defines assertions on the response's body
+func hasHTTPBodyAssertions(c *Check) bool {
+	config := c.Config.Map()
+	for _, key := range []string{"bodyContains", "bodyNotContains", "bodyRegexp", "bodyNotRegexp", "jsonPath"} {
+		if _, ok := config[key]; ok {
+			return true
+		}
+	}
+
+	return false
+}
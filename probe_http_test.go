@@ -1,6 +1,7 @@
 package poller
 
 import (
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -78,3 +79,162 @@ func TestTimeoutedTest(t *testing.T) {
 		t.Error("IsUp() should be false")
 	}
 }
+
+type assertionsTestHandler struct {
+}
+
+func (p assertionsTestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Version", "42")
+	w.WriteHeader(202)
+	w.Write([]byte(`{"status": "ok", "checks": [{"name": "db", "healthy": true}]}`))
+}
+
+func TestHTTPAssertionsTest(t *testing.T) {
+	server := httptest.NewServer(assertionsTestHandler{})
+	defer server.Close()
+
+	probe := NewHttpProbe("foobar", 10*time.Second)
+
+	c, _ := NewCheck("foobar", "10s", false, "", false, make(map[string]interface{}))
+	c.Config.Set("url", server.URL)
+	event := probe.Test(c)
+	if event.IsUp() != false {
+		t.Error("IsUp() should be false as 202 is not accepted by default")
+	}
+	if event.Details["failedAssertion"] != "status code 202 is not accepted" {
+		t.Errorf("failedAssertion is wrong. Got %v", event.Details["failedAssertion"])
+	}
+
+	c.Config.Set("statusCodes", []string{"2xx"})
+	c.Config.Set("responseHeaders", map[string]string{"X-Version": "42", "Content-Type": ""})
+	c.Config.Set("bodyContains", `"status"`)
+	c.Config.Set("bodyNotRegexp", "error|failure")
+	c.Config.Set("jsonPath", map[string]interface{}{"$.status": "ok", "$.checks[0].healthy": true})
+	event = probe.Test(c)
+	if event.IsUp() != true {
+		t.Errorf("IsUp() should be true. Got failed assertion %v", event.Details["failedAssertion"])
+	}
+
+	c.Config.Set("jsonPath", map[string]interface{}{"$.checks[0].name": "cache"})
+	event = probe.Test(c)
+	if event.IsUp() != false {
+		t.Error("IsUp() should be false when a JSON path assertion fails")
+	}
+	if event.Details["failedAssertion"] != `$.checks[0].name is "db" instead of "cache"` {
+		t.Errorf("failedAssertion is wrong. Got %v", event.Details["failedAssertion"])
+	}
+
+	// The first failed path, in order, is reported on every poll
+	c.Config.Set("jsonPath", map[string]interface{}{"$.status": "ko", "$.checks[0].name": "cache", "$.checks[0].healthy": false})
+	for i := 0; i < 10; i++ {
+		event = probe.Test(c)
+		if event.Details["failedAssertion"] != `$.checks[0].healthy is true instead of false` {
+			t.Log("failedAssertion is wrong. Got", event.Details["failedAssertion"])
+			t.FailNow()
+		}
+	}
+
+	c.Config.Set("jsonPath", map[string]interface{}{})
+	c.Config.Set("bodyNotRegexp", "(")
+	event = probe.Test(c)
+	if event.IsUp() != false || event.Details["error"] == nil || event.Details["failedAssertion"] != nil {
+		t.Errorf("An invalid regexp should be reported as an error. Got %v", event.Details)
+	}
+}
+
+type echoMethodTestHandler struct {
+}
+
+func (p echoMethodTestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/redirect" {
+		http.Redirect(w, r, "/login", 302)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	w.Write([]byte(r.Method + " " + string(body)))
+}
+
+func TestHTTPMethodAndBodyTest(t *testing.T) {
+	server := httptest.NewServer(echoMethodTestHandler{})
+	defer server.Close()
+
+	probe := NewHttpProbe("foobar", 10*time.Second)
+
+	c, _ := NewCheck("foobar", "10s", false, "", false, make(map[string]interface{}))
+	c.Config.Set("url", server.URL)
+	c.Config.Set("method", "POST")
+	c.Config.Set("body", `{"ping":true}`)
+	c.Config.Set("bodyContains", `POST {"ping":true}`)
+	event := probe.Test(c)
+	if event.IsUp() != true {
+		t.Errorf("IsUp() should be true. Got failed assertion %v", event.Details["failedAssertion"])
+	}
+}
+
+func TestHTTPRedirectPolicyTest(t *testing.T) {
+	server := httptest.NewServer(echoMethodTestHandler{})
+	defer server.Close()
+
+	probe := NewHttpProbe("foobar", 10*time.Second)
+
+	c, _ := NewCheck("foobar", "10s", false, "", false, make(map[string]interface{}))
+	c.Config.Set("url", server.URL+"/redirect")
+	if event := probe.Test(c); event.IsUp() != true {
+		t.Error("IsUp() should be true when the redirect is followed")
+	}
+
+	c.Config.Set("followRedirects", false)
+	event := probe.Test(c)
+	if event.IsUp() != false {
+		t.Error("IsUp() should be false when the redirect is not followed")
+	}
+	if event.StatusCode != 302 {
+		t.Errorf("statusCode should be 302. Got %d", event.StatusCode)
+	}
+
+	c.Config.Set("followRedirects", true)
+	c.Config.Set("maxRedirects", 0)
+	if event := probe.Test(c); event.IsUp() != false {
+		t.Error("IsUp() should be false when there are too many redirects")
+	}
+}
+
+func TestCheckTimeoutTest(t *testing.T) {
+	server := httptest.NewServer(timeoutTestHandler{})
+	defer server.Close()
+
+	probe := NewHttpProbe("foobar", 10*time.Second)
+
+	c, _ := NewCheck("foobar", "10s", false, "", false, make(map[string]interface{}))
+	c.Config.Set("url", server.URL)
+	c.Timeout = 100 * time.Millisecond
+	event := probe.Test(c)
+	if event.IsUp() != false {
+		t.Error("IsUp() should be false as the check's timeout is shorter than the response time")
+	}
+	if event.Duration > time.Second {
+		t.Error("The check's timeout should be used instead of the probe's timeout")
+	}
+}
+
+func TestCheckTimeoutLateResponse(t *testing.T) {
+	server := httptest.NewServer(timeoutTestHandler{})
+	defer server.Close()
+
+	probe := NewHttpProbe("foobar", 10*time.Second)
+
+	c, _ := NewCheck("foobar", "10s", false, "", false, make(map[string]interface{}))
+	c.Config.Set("url", server.URL)
+	c.Timeout = 100 * time.Millisecond
+	event := probe.Test(c)
+
+	// Leave time for the response to arrive after the timeout
+	time.Sleep(300 * time.Millisecond)
+	if event.IsUp() != false {
+		t.Error("A response received after the timeout should not mark the event up")
+	}
+	if c.DownSince.IsZero() {
+		t.Error("A response received after the timeout should not reset DownSince")
+	}
+}
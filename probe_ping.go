@@ -0,0 +1,143 @@
+package poller
+
+import (
+	"bytes"
+	"crypto/rand"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+	"net"
+	"time"
+)
+
+type pingProbe struct {
+	Timeout time.Duration
+}
+
+// Instantiates a Probe which sends "count" ICMP echo requests to the check's "host".
+// Unprivileged ICMP sockets are used where the OS allows them, raw sockets otherwise.
+// The event's details hold the number of "sent" and "received" packets, the packet "loss" percentage
+// and the "minRtt", "avgRtt" and "maxRtt" round trip times.
+// The check is down if the loss percentage is above the "maxLoss" of its config.
+func NewPingProbe(timeout time.Duration) Probe {
+	return &pingProbe{timeout}
+}
+
+// Returns the details of a ping which received the rtts replies out of count requests, and whether the
+// check is up: at least one reply was received and the loss percentage is not above maxLoss.
+func pingStatistics(count int, rtts []time.Duration, maxLoss int) (map[string]interface{}, bool) {
+	details := make(map[string]interface{})
+	loss := float64(count-len(rtts)) * 100 / float64(count)
+	details["sent"] = count
+	details["received"] = len(rtts)
+	details["loss"] = loss
+	if len(rtts) == 0 {
+		return details, false
+	}
+
+	min, max, total := rtts[0], rtts[0], time.Duration(0)
+	for _, rtt := range rtts {
+		if rtt < min {
+			min = rtt
+		}
+		if rtt > max {
+			max = rtt
+		}
+		total += rtt
+	}
+	details["minRtt"] = min
+	details["avgRtt"] = total / time.Duration(len(rtts))
+	details["maxRtt"] = max
+
+	return details, loss <= float64(maxLoss)
+}
+
+// Opens an ICMP socket for the address family of ip, preferring unprivileged sockets.
+// The returned bool is true if the socket is unprivileged.
+func listenICMP(ip net.IP) (*icmp.PacketConn, bool, error) {
+	network, privilegedNetwork, address := "udp4", "ip4:icmp", "0.0.0.0"
+	if ip.To4() == nil {
+		network, privilegedNetwork, address = "udp6", "ip6:ipv6-icmp", "::"
+	}
+
+	if conn, err := icmp.ListenPacket(network, address); err == nil {
+		return conn, true, nil
+	}
+	conn, err := icmp.ListenPacket(privilegedNetwork, address)
+	return conn, false, err
+}
+
+func (p *pingProbe) Test(c *Check) *Event {
+	timeout := c.TimeoutOr(p.Timeout)
+
+	return testWithTimeout(c, timeout, func(now time.Time) probeResult {
+		addr, err := net.ResolveIPAddr("ip", c.Config.GetString("host"))
+		if err != nil {
+			return probeResult{err: err}
+		}
+		conn, unprivileged, err := listenICMP(addr.IP)
+		if err != nil {
+			return probeResult{err: err}
+		}
+		defer conn.Close()
+
+		var dst net.Addr = addr
+		if unprivileged {
+			dst = &net.UDPAddr{IP: addr.IP, Zone: addr.Zone}
+		}
+
+		var requestType, replyType icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+		if addr.IP.To4() == nil {
+			requestType, replyType = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+		}
+
+		count := c.Config.GetInt("count")
+		if count <= 0 {
+			count = 1
+		}
+		// Leave some room so that statistics are computed before the probe times out
+		wait := timeout / time.Duration(count+1)
+
+		// The kernel rewrites the identifier of unprivileged echo requests, so replies are matched on their payload
+		payload := make([]byte, 16)
+		if _, err := rand.Read(payload); err != nil {
+			return probeResult{err: err}
+		}
+		id := int(payload[0])<<8 | int(payload[1])
+		rtts := make([]time.Duration, 0, count)
+		buf := make([]byte, 1500)
+
+		for seq := 0; seq < count; seq++ {
+			message := icmp.Message{Type: requestType, Body: &icmp.Echo{ID: id, Seq: seq, Data: payload}}
+			packet, err := message.Marshal(nil)
+			if err != nil {
+				return probeResult{err: err}
+			}
+
+			sent := time.Now()
+			if _, err := conn.WriteTo(packet, dst); err != nil {
+				continue
+			}
+			conn.SetReadDeadline(sent.Add(wait))
+			for {
+				n, _, err := conn.ReadFrom(buf)
+				if err != nil {
+					break
+				}
+				reply, err := icmp.ParseMessage(requestType.Protocol(), buf[:n])
+				if err != nil || reply.Type != replyType {
+					continue
+				}
+				echo, ok := reply.Body.(*icmp.Echo)
+				if !ok || echo.Seq != seq || !bytes.Equal(echo.Data, payload) {
+					continue
+				}
+				rtts = append(rtts, time.Since(sent))
+				break
+			}
+		}
+
+		details, up := pingStatistics(count, rtts, c.Config.GetInt("maxLoss"))
+		return probeResult{up: up, details: details}
+	})
+}
@@ -0,0 +1,62 @@
+package poller
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPingSuccessfullTest(t *testing.T) {
+	if conn, _, err := listenICMP(net.ParseIP("127.0.0.1")); err != nil {
+		t.Skip("ICMP sockets are not allowed:", err)
+	} else {
+		conn.Close()
+	}
+
+	probe := NewPingProbe(10 * time.Second)
+
+	c, _ := NewCheck("foobar", "10s", false, "", false, make(map[string]interface{}))
+	c.Config.Set("host", "127.0.0.1")
+	c.Config.Set("count", 3)
+	event := probe.Test(c)
+	if event.IsUp() != true {
+		t.Errorf("IsUp() should be true. Details: %v", event.Details)
+	}
+	if event.Details["loss"] != float64(0) {
+		t.Errorf("loss should be 0. Got %v", event.Details["loss"])
+	}
+	if event.Details["received"] != 3 {
+		t.Errorf("received should be 3. Got %v", event.Details["received"])
+	}
+	if _, ok := event.Details["avgRtt"].(time.Duration); !ok {
+		t.Error("avgRtt should be set")
+	}
+}
+
+func TestPingStatistics(t *testing.T) {
+	details, up := pingStatistics(4, []time.Duration{10 * time.Millisecond, 30 * time.Millisecond, 20 * time.Millisecond}, 25)
+	if up != true {
+		t.Error("A 25% loss should be up when maxLoss is 25")
+	}
+	if details["sent"] != 4 || details["received"] != 3 || details["loss"] != float64(25) {
+		t.Errorf("Statistics are wrong. Got %v", details)
+	}
+	if details["minRtt"] != 10*time.Millisecond || details["avgRtt"] != 20*time.Millisecond || details["maxRtt"] != 30*time.Millisecond {
+		t.Errorf("Round trip times are wrong. Got %v", details)
+	}
+
+	if _, up := pingStatistics(4, []time.Duration{10 * time.Millisecond, 30 * time.Millisecond, 20 * time.Millisecond}, 0); up != false {
+		t.Error("Any loss should be down when maxLoss is 0")
+	}
+
+	details, up = pingStatistics(3, nil, 100)
+	if up != false {
+		t.Error("A ping without reply should be down, whatever maxLoss is")
+	}
+	if details["loss"] != float64(100) {
+		t.Errorf("loss should be 100. Got %v", details["loss"])
+	}
+	if _, ok := details["avgRtt"]; ok {
+		t.Error("avgRtt should not be set without reply")
+	}
+}
@@ -0,0 +1,74 @@
+package poller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// A ProbeRegistry is a Probe which routes each check to the probe registered for its type.
+// It allows a single Poller to run checks of different types.
+type ProbeRegistry interface {
+	Probe
+	// Register routes checks of type t to probe. If configurator is not nil, it is registered
+	// as well so that checks of type t can be read by NewCheckFromJSON.
+	// An error is returned if a probe, or a configurator, is already registered for t.
+	Register(t CheckType, probe Probe, configurator CheckConfigurator) error
+}
+
+type probeRegistry struct {
+	probes map[CheckType]Probe
+	mu     sync.RWMutex
+}
+
+// Instantiates an empty ProbeRegistry.
+func NewProbeRegistry() ProbeRegistry {
+	return &probeRegistry{probes: make(map[CheckType]Probe)}
+}
+
+// Instantiates a ProbeRegistry with the probes of this package registered for their check type:
+// http (sending ua as User-Agent), tcp, udp, tls, dns and ping, all using timeout by default.
+func NewDefaultProbeRegistry(ua string, timeout time.Duration) ProbeRegistry {
+	r := NewProbeRegistry()
+	r.Register(CheckTypeHTTP, NewHttpProbe(ua, timeout), nil)
+	r.Register(CheckTypeTCP, NewTcpProbe(timeout), nil)
+	r.Register(CheckTypeUDP, NewUdpProbe(timeout), nil)
+	r.Register(CheckTypeTLS, NewTlsProbe(timeout), nil)
+	r.Register(CheckTypeDNS, NewDnsProbe(timeout), nil)
+	r.Register(CheckTypePing, NewPingProbe(timeout), nil)
+
+	return r
+}
+
+func (r *probeRegistry) Register(t CheckType, probe Probe, configurator CheckConfigurator) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.probes[t]; ok {
+		return fmt.Errorf("A probe is already registered for check type %s", t)
+	}
+	if configurator != nil {
+		if err := RegisterCheckConfigurator(t, configurator); err != nil {
+			return err
+		}
+	}
+	r.probes[t] = probe
+
+	return nil
+}
+
+// Test polls the check with the probe registered for its type.
+// If no probe is registered, the check is down and the event's details hold the "error".
+func (r *probeRegistry) Test(c *Check) *Event {
+	r.mu.RLock()
+	probe, ok := r.probes[c.Type()]
+	r.mu.RUnlock()
+
+	if !ok {
+		event := NewEvent(c)
+		event.fail(fmt.Errorf("No probe registered for check type %s", c.Type()))
+		return event
+	}
+
+	return probe.Test(c)
+}
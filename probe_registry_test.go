@@ -0,0 +1,79 @@
+package poller
+
+import (
+	"github.com/bitly/go-simplejson"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type upTestProbe struct {
+}
+
+func (p upTestProbe) Test(c *Check) *Event {
+	event := NewEvent(c)
+	event.Up()
+	return event
+}
+
+func TestProbeRegistry(t *testing.T) {
+	registry := NewProbeRegistry()
+	err := registry.Register("always_up", upTestProbe{}, func(c *Check, js *simplejson.Json) error {
+		c.Config.Set("name", js.Get("config").Get("name").MustString())
+		return nil
+	})
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	defer func() {
+		checkConfiguratorsMu.Lock()
+		delete(checkConfigurators, "always_up")
+		checkConfiguratorsMu.Unlock()
+	}()
+
+	if err := registry.Register("always_up", upTestProbe{}, nil); err == nil {
+		t.Error("Registering a probe twice for the same check type should return an error")
+	}
+	if err := NewProbeRegistry().Register(CheckTypeHTTP, upTestProbe{}, readHTTPConfig); err == nil {
+		t.Error("Registering a configurator twice for the same check type should return an error")
+	}
+
+	check, err := NewCheckFromJSON([]byte(`{"type": "always_up", "key": "foobar", "interval": "10s", "alert": false, "alertDelay": "0s", "notifyFix": false, "config": {"name": "foo"}}`))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	if check.Config.GetString("name") != "foo" {
+		t.Error("Registered configurator should have been used")
+	}
+	if registry.Test(check).IsUp() != true {
+		t.Error("IsUp() should be true")
+	}
+
+	check, _ = NewCheck("foobar", "10s", false, "", false, make(map[string]interface{}))
+	event := registry.Test(check)
+	if event.IsUp() != false {
+		t.Error("IsUp() should be false for unregistered check types")
+	}
+	if _, ok := event.Details["error"]; !ok {
+		t.Error("error should be set for unregistered check types")
+	}
+}
+
+func TestDefaultProbeRegistry(t *testing.T) {
+	registry := NewDefaultProbeRegistry("poller", 10*time.Second).(*probeRegistry)
+	for _, checkType := range []CheckType{CheckTypeHTTP, CheckTypeTCP, CheckTypeUDP, CheckTypeTLS, CheckTypeDNS, CheckTypePing} {
+		if _, ok := registry.probes[checkType]; !ok {
+			t.Errorf("A probe should be registered for %s checks", checkType)
+		}
+	}
+
+	server := httptest.NewServer(successTestHandler{})
+	defer server.Close()
+
+	check, _ := NewCheckFromJSON([]byte(`{"type": "http", "key": "foobar", "interval": "10s", "alert": false, "alertDelay": "0s", "notifyFix": false, "config": {"url": "` + server.URL + `"}}`))
+	if registry.Test(check).IsUp() != true {
+		t.Error("IsUp() should be true")
+	}
+}
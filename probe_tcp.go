@@ -0,0 +1,68 @@
+package poller
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+type tcpProbe struct {
+	Timeout time.Duration
+
+	regexps regexpCache // compiled "receiveRegexp"
+}
+
+// Instantiates a Probe which checks that a TCP port accepts connections.
+// If the check's config defines "send", the payload is written once connected.
+// The reply is then compared to "receive", or matched against the "receiveRegexp" regular expression.
+func NewTcpProbe(timeout time.Duration) Probe {
+	return &tcpProbe{Timeout: timeout}
+}
+
+func (p *tcpProbe) Test(c *Check) *Event {
+	timeout := c.TimeoutOr(p.Timeout)
+
+	return testWithTimeout(c, timeout, func(now time.Time) probeResult {
+		hp := net.JoinHostPort(c.Config.GetString("host"), strconv.Itoa(c.Config.GetInt("port")))
+		conn, err := net.DialTimeout("tcp", hp, timeout)
+		if err != nil {
+			return probeResult{err: err}
+		}
+		conn.SetDeadline(time.Now().Add(timeout))
+		defer conn.Close()
+
+		if send := c.Config.GetString("send"); send != "" {
+			if _, err := conn.Write([]byte(send)); err != nil {
+				return probeResult{err: err}
+			}
+		}
+
+		if receive := c.Config.GetString("receive"); receive != "" {
+			buf := make([]byte, len([]byte(receive)))
+			if _, err := io.ReadFull(conn, buf); err != nil {
+				return probeResult{err: err}
+			}
+			if string(buf) != receive {
+				return probeResult{}
+			}
+		}
+
+		if expr := c.Config.GetString("receiveRegexp"); expr != "" {
+			re, err := p.regexps.compile(expr)
+			if err != nil {
+				return probeResult{err: err}
+			}
+			buf := make([]byte, 4096)
+			count, err := conn.Read(buf)
+			if err != nil {
+				return probeResult{err: err}
+			}
+			if !re.Match(buf[:count]) {
+				return probeResult{}
+			}
+		}
+
+		return probeResult{up: true}
+	})
+}
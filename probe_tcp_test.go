@@ -0,0 +1,127 @@
+package poller
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func newTestTCPServer(t *testing.T, reply string) net.Listener {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			buf := make([]byte, 16)
+			conn.Read(buf)
+			conn.Write([]byte(reply))
+			conn.Close()
+		}
+	}()
+
+	return listener
+}
+
+func newTestTCPCheck(listener net.Listener) *Check {
+	c, _ := NewCheck("foobar", "10s", false, "", false, make(map[string]interface{}))
+	c.Config.Set("host", "localhost")
+	c.Config.Set("port", listener.Addr().(*net.TCPAddr).Port)
+	c.Config.Set("send", "PING\r\n")
+
+	return c
+}
+
+func TestTCPSuccessfullTest(t *testing.T) {
+	listener := newTestTCPServer(t, "+PONG\r\n")
+	defer listener.Close()
+
+	probe := NewTcpProbe(10 * time.Second)
+
+	c := newTestTCPCheck(listener)
+	c.Config.Set("receive", "+PONG")
+	event := probe.Test(c)
+	if event.IsUp() != true {
+		t.Error("IsUp() should be true")
+	}
+	if event.Duration.Nanoseconds() == 0 {
+		t.Error("Duration can't be equals to 0 nanosecond")
+	}
+
+	c = newTestTCPCheck(listener)
+	c.Config.Set("receiveRegexp", "^\\+PO[N]G")
+	if probe.Test(c).IsUp() != true {
+		t.Error("IsUp() should be true when the reply matches the regexp")
+	}
+}
+
+func TestTCPFailedTest(t *testing.T) {
+	listener := newTestTCPServer(t, "-ERR\r\n")
+	defer listener.Close()
+
+	probe := NewTcpProbe(10 * time.Second)
+
+	c := newTestTCPCheck(listener)
+	c.Config.Set("receive", "+PONG")
+	if probe.Test(c).IsUp() != false {
+		t.Error("IsUp() should be false")
+	}
+	if c.DownSince.IsZero() {
+		t.Error("DownSince should be set")
+	}
+
+	c = newTestTCPCheck(listener)
+	c.Config.Set("port", 0)
+	if probe.Test(c).IsUp() != false {
+		t.Error("IsUp() should be false when connection is refused")
+	}
+	if c.DownSince.IsZero() {
+		t.Error("DownSince should be set when connection is refused")
+	}
+
+	// The server closes the connection without replying
+	silent := newTestTCPServer(t, "")
+	defer silent.Close()
+	c = newTestTCPCheck(silent)
+	c.Config.Set("receiveRegexp", "^\\+PONG")
+	if probe.Test(c).IsUp() != false {
+		t.Error("IsUp() should be false when nothing is received")
+	}
+	if c.DownSince.IsZero() {
+		t.Error("DownSince should be set when nothing is received")
+	}
+}
+
+func TestTCPRefusedConnectionAlerts(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	// Nothing listens on the port once the listener is closed
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	c, _ := NewCheck("foobar", "10s", true, "0s", false, make(map[string]interface{}))
+	c.Config.Set("host", "localhost")
+	c.Config.Set("port", port)
+
+	event := NewTcpProbe(10 * time.Second).Test(c)
+	if event.IsUp() != false {
+		t.Error("IsUp() should be false when connection is refused")
+	}
+	if c.DownSince.IsZero() {
+		t.Error("DownSince should be set when connection is refused")
+	}
+	if !event.Alert {
+		t.Error("A refused connection should raise an alert")
+	}
+	if _, ok := event.Details["error"]; !ok {
+		t.Error("The connection error should be stored in the event's details")
+	}
+}
@@ -0,0 +1,132 @@
+package poller
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type tlsProbe struct {
+	Timeout time.Duration
+	RootCAs *x509.CertPool // nil means the system's pool
+
+	pools map[string]*x509.CertPool // pools of the checks' "caFile", by path
+	mu    sync.Mutex
+}
+
+// Instantiates a Probe which performs a TLS handshake and inspects the certificate chain.
+// The event's details hold the days until the first certificate of the chain expires ("daysUntilExpiry"),
+// its expiration date ("notAfter"), the leaf's issuer ("issuer") and the verification error, if any ("verifyError").
+// The check is down if the chain does not verify or expires in less than the "minValidDays" of its config.
+// The chain is verified against the system's pool, or against the PEM bundle at the "caFile" of the check's config.
+func NewTlsProbe(timeout time.Duration) Probe {
+	return NewTlsProbeWithRootCAs(timeout, nil)
+}
+
+// Instantiates a TLS Probe which verifies chains against rootCAs instead of the system's pool,
+// ie: to monitor endpoints signed by a private CA. Checks defining a "caFile" still use their own.
+func NewTlsProbeWithRootCAs(timeout time.Duration, rootCAs *x509.CertPool) Probe {
+	return &tlsProbe{Timeout: timeout, RootCAs: rootCAs, pools: make(map[string]*x509.CertPool)}
+}
+
+// Returns the pool chains of the check are verified against
+func (p *tlsProbe) rootCAs(c *Check) (*x509.CertPool, error) {
+	path := c.Config.GetString("caFile")
+	if path == "" {
+		return p.RootCAs, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pool, ok := p.pools[path]; ok {
+		return pool, nil
+	}
+	pool, err := loadCertPool(path)
+	if err != nil {
+		return nil, err
+	}
+	p.pools[path] = pool
+
+	return pool, nil
+}
+
+// Returns a pool of the PEM encoded certificates of the file at path
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("No certificate found in %s", path)
+	}
+
+	return pool, nil
+}
+
+func (p *tlsProbe) Test(c *Check) *Event {
+	timeout := c.TimeoutOr(p.Timeout)
+
+	return testWithTimeout(c, timeout, func(now time.Time) probeResult {
+		host := c.Config.GetString("host")
+		serverName := c.Config.GetString("serverName")
+		if serverName == "" {
+			serverName = host
+		}
+
+		roots, err := p.rootCAs(c)
+		if err != nil {
+			return probeResult{err: err}
+		}
+
+		hp := net.JoinHostPort(host, strconv.Itoa(c.Config.GetInt("port")))
+		dialer := &net.Dialer{Timeout: timeout}
+
+		// Verification is done below so that its error can be reported
+		conn, err := tls.DialWithDialer(dialer, "tcp", hp, &tls.Config{ServerName: serverName, InsecureSkipVerify: true})
+		if err != nil {
+			return probeResult{err: err}
+		}
+		defer conn.Close()
+
+		certificates := conn.ConnectionState().PeerCertificates
+		if len(certificates) == 0 {
+			return probeResult{}
+		}
+
+		details := make(map[string]interface{})
+		notAfter := certificates[0].NotAfter
+		for _, cert := range certificates[1:] {
+			if cert.NotAfter.Before(notAfter) {
+				notAfter = cert.NotAfter
+			}
+		}
+		daysUntilExpiry := int(notAfter.Sub(now).Hours() / 24)
+		details["notAfter"] = notAfter
+		details["daysUntilExpiry"] = daysUntilExpiry
+		details["issuer"] = certificates[0].Issuer.String()
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certificates[1:] {
+			intermediates.AddCert(cert)
+		}
+		opts := x509.VerifyOptions{
+			DNSName:       serverName,
+			Roots:         roots,
+			Intermediates: intermediates,
+			CurrentTime:   now}
+		_, verifyErr := certificates[0].Verify(opts)
+		if verifyErr != nil {
+			details["verifyError"] = verifyErr.Error()
+		}
+
+		up := verifyErr == nil && !notAfter.Before(now) && daysUntilExpiry >= c.Config.GetInt("minValidDays")
+		return probeResult{up: up, details: details}
+	})
+}
@@ -0,0 +1,108 @@
+package poller
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newTestTLSCheck(server *httptest.Server) *Check {
+	u, _ := url.Parse(server.URL)
+	host, port, _ := net.SplitHostPort(u.Host)
+	p, _ := strconv.Atoi(port)
+
+	c, _ := NewCheck("foobar", "10s", false, "", false, make(map[string]interface{}))
+	c.Config.Set("host", host)
+	c.Config.Set("port", p)
+
+	return c
+}
+
+func TestTLSSuccessfullTest(t *testing.T) {
+	server := httptest.NewTLSServer(successTestHandler{})
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	probe := NewTlsProbeWithRootCAs(10*time.Second, pool)
+
+	event := probe.Test(newTestTLSCheck(server))
+	if event.IsUp() != true {
+		t.Errorf("IsUp() should be true. Details: %v", event.Details)
+	}
+	if event.Details["daysUntilExpiry"].(int) <= 0 {
+		t.Error("daysUntilExpiry should be positive")
+	}
+	if _, ok := event.Details["verifyError"]; ok {
+		t.Error("verifyError should not be set")
+	}
+}
+
+func TestTLSFailedTest(t *testing.T) {
+	server := httptest.NewTLSServer(successTestHandler{})
+	defer server.Close()
+
+	// The test server's certificate is not trusted by the system's pool
+	event := NewTlsProbe(10 * time.Second).Test(newTestTLSCheck(server))
+	if event.IsUp() != false {
+		t.Error("IsUp() should be false")
+	}
+	if _, ok := event.Details["verifyError"]; !ok {
+		t.Error("verifyError should be set")
+	}
+	if event.Check.DownSince.IsZero() {
+		t.Error("DownSince should be set")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	probe := NewTlsProbeWithRootCAs(10*time.Second, pool)
+
+	c := newTestTLSCheck(server)
+	c.Config.Set("minValidDays", 1000000)
+	if probe.Test(c).IsUp() != false {
+		t.Error("IsUp() should be false when the certificate expires before minValidDays")
+	}
+
+	// Nothing listens on the port once the server is closed
+	c = newTestTLSCheck(server)
+	server.Close()
+	if probe.Test(c).IsUp() != false {
+		t.Error("IsUp() should be false when connection is refused")
+	}
+	if c.DownSince.IsZero() {
+		t.Error("DownSince should be set when connection is refused")
+	}
+}
+
+func TestTLSCAFile(t *testing.T) {
+	server := httptest.NewTLSServer(successTestHandler{})
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0644)
+
+	u, _ := url.Parse(server.URL)
+	host, port, _ := net.SplitHostPort(u.Host)
+	check, err := NewCheckFromJSON([]byte(`{"type": "tls", "key": "foobar", "interval": "10s", "alert": false, "alertDelay": "0s", "notifyFix": false, "config": {"host": "` + host + `", "port": ` + port + `, "caFile": "` + caFile + `"}}`))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	event := NewTlsProbe(10 * time.Second).Test(check)
+	if event.IsUp() != true {
+		t.Errorf("IsUp() should be true when the certificate is signed by the caFile. Details: %v", event.Details)
+	}
+
+	if _, err := NewCheckFromJSON([]byte(`{"type": "tls", "key": "foobar", "interval": "10s", "alert": false, "alertDelay": "0s", "notifyFix": false, "config": {"host": "localhost", "caFile": "/does/not/exist"}}`)); err == nil {
+		t.Error("A missing caFile should be rejected")
+	}
+}
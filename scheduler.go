@@ -14,28 +14,38 @@ type Scheduler interface {
 }
 
 type simpleScheduler struct {
-	stopSignals map[string]chan int // collection of channels which are used to signal a goroutine to abandon ship immediately
-	toPoll      chan *Check         // checks which are due to polling
-	toSchedule  chan *Check         // checks which are due to scheduling
+	stopSignals map[string]chan int  // collection of channels which are used to signal a goroutine to abandon ship immediately
+	toPoll      chan *Check          // checks which are due to polling
+	toSchedule  chan *scheduledCheck // checks which are due to scheduling
 	mu          sync.Mutex
 }
 
+// A check due to scheduling, along with the stop signal of the schedule it comes from
+type scheduledCheck struct {
+	check  *Check
+	signal chan int
+}
+
 // Instantiates a SimpleScheduler which scheduling strategy's fairly basic.
 // For each scheduled check, a new time.Timer is created in its own goroutine.
 func NewSimpleScheduler() Scheduler {
 	return &simpleScheduler{
 		stopSignals: make(map[string]chan int),
 		toPoll:      make(chan *Check),
-		toSchedule:  make(chan *Check)}
+		toSchedule:  make(chan *scheduledCheck)}
 }
 
-func (s *simpleScheduler) schedule(check *Check, deleteSignal <-chan int) {
+func (s *simpleScheduler) schedule(check *Check, deleteSignal chan int) {
 	timer := time.NewTimer(check.Interval)
 	select {
 	case <-timer.C:
-		s.toSchedule <- check
+		// The check may be stopped while Start is busy
+		select {
+		case s.toSchedule <- &scheduledCheck{check, deleteSignal}:
+		case <-deleteSignal:
+		}
 	case <-deleteSignal:
-		break
+		timer.Stop()
 	}
 }
 
@@ -43,14 +53,20 @@ func (s *simpleScheduler) Schedule(check *Check) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// Rescheduling a check replaces the previous schedule
+	s.stop(check.Key)
 	s.stopSignals[check.Key] = make(chan int)
 	go s.schedule(check, s.stopSignals[check.Key])
 	return nil
 }
 
+// Closing the channel signals the goroutine without blocking if the check is not currently waiting on its timer.
 func (s *simpleScheduler) stop(key string) {
-	s.stopSignals[key] <- 1
-	close(s.stopSignals[key])
+	signal, ok := s.stopSignals[key]
+	if !ok {
+		return
+	}
+	close(signal)
 	delete(s.stopSignals, key)
 }
 
@@ -72,9 +88,17 @@ func (s *simpleScheduler) StopAll() {
 
 func (s *simpleScheduler) Start() {
 	for {
-		check := <-s.toSchedule
-		go s.schedule(check, s.stopSignals[check.Key])
-		s.toPoll <- check
+		scheduled := <-s.toSchedule
+		s.mu.Lock()
+		signal, ok := s.stopSignals[scheduled.check.Key]
+		s.mu.Unlock()
+
+		// The check has been stopped, or replaced by another schedule, while it was waiting to be rescheduled
+		if !ok || signal != scheduled.signal {
+			continue
+		}
+		go s.schedule(scheduled.check, signal)
+		s.toPoll <- scheduled.check
 	}
 }
 
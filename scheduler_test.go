@@ -0,0 +1,27 @@
+package poller
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSimpleSchedulerReplacedCheck(t *testing.T) {
+	s := NewSimpleScheduler()
+
+	old, _ := NewCheck("foobar", "10ms", false, "", false, make(map[string]interface{}))
+	s.Schedule(old)
+	// Let the old check become due while nothing reschedules it
+	time.Sleep(50 * time.Millisecond)
+
+	replacement, _ := NewCheck("foobar", "10ms", false, "", false, make(map[string]interface{}))
+	s.Schedule(replacement)
+	go s.Start()
+	defer s.StopAll()
+
+	for i := 0; i < 5; i++ {
+		if check := <-s.Next(); check != replacement {
+			t.Log("Only the replacement check should be polled")
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package poller
+
+import (
+	"encoding/json"
+	"go.etcd.io/bbolt"
+	"time"
+)
+
+var (
+	boltChecksBucket = []byte("checks")
+	boltStatesBucket = []byte("states")
+)
+
+// Runtime state of a check, persisted alongside its definition
+type checkState struct {
+	UpSince    time.Time     `json:"upSince"`
+	DownSince  time.Time     `json:"downSince"`
+	WasDownFor time.Duration `json:"wasDownFor"`
+	WasUpFor   time.Duration `json:"wasUpFor"`
+	Alerted    bool          `json:"alerted"`
+
+	Escalated    int  `json:"escalated,omitempty"`
+	Acknowledged bool `json:"acknowledged,omitempty"`
+}
+
+func newCheckState(c *Check) *checkState {
+	return &checkState{
+		UpSince:    c.UpSince,
+		DownSince:  c.DownSince,
+		WasDownFor: c.WasDownFor,
+		WasUpFor:   c.WasUpFor,
+		Alerted:    c.Alerted,
+
+		Escalated:    c.Escalated,
+		Acknowledged: c.Acknowledged}
+}
+
+func (s *checkState) apply(c *Check) {
+	c.UpSince = s.UpSince
+	c.DownSince = s.DownSince
+	c.WasDownFor = s.WasDownFor
+	c.WasUpFor = s.WasUpFor
+	c.Alerted = s.Alerted
+	c.Acknowledged = s.Acknowledged
+
+	// The escalation may have been shortened since the state was persisted
+	c.Escalated = s.Escalated
+	if c.Escalated > len(c.Escalation) {
+		c.Escalated = len(c.Escalation)
+	}
+}
+
+// A boltStore persists checks definitions and their runtime state in a bbolt database.
+// Checks are kept in memory as well, so the pointers handed to the Scheduler are the ones being updated by probes.
+type boltStore struct {
+	db     *bbolt.DB
+	memory *inMemoryStore
+}
+
+// Instantiates a new Store backed by the bbolt database at path, which is created if needed.
+// Checks and their last known state are loaded before the store is returned, so that ScheduleAll
+// resumes checks where they were.
+// The returned StateStore is also a Backend: log events to it to persist the state of checks after each poll.
+func NewBoltStore(path string) (StateStore, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &boltStore{db: db, memory: &inMemoryStore{list: make(map[string]*Check)}}
+	if err := s.load(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *boltStore) load() error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		checks, err := tx.CreateBucketIfNotExists(boltChecksBucket)
+		if err != nil {
+			return err
+		}
+		states, err := tx.CreateBucketIfNotExists(boltStatesBucket)
+		if err != nil {
+			return err
+		}
+
+		return checks.ForEach(func(k, v []byte) error {
+			check, err := NewCheckFromJSON(v)
+			if err != nil {
+				return err
+			}
+			if data := states.Get(k); data != nil {
+				state := &checkState{}
+				if err := json.Unmarshal(data, state); err != nil {
+					return err
+				}
+				state.apply(check)
+			}
+
+			return s.memory.Add(check)
+		})
+	})
+}
+
+// Add an element to the store and persist its definition and state
+func (s *boltStore) Add(check *Check) error {
+	definition, err := check.JSON()
+	if err != nil {
+		return err
+	}
+	state, err := json.Marshal(newCheckState(check))
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket(boltChecksBucket).Put([]byte(check.Key), definition); err != nil {
+			return err
+		}
+		return tx.Bucket(boltStatesBucket).Put([]byte(check.Key), state)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.memory.Add(check)
+}
+
+// Returns the element key from the store. If key is not present, nil is returned.
+func (s *boltStore) Get(key string) (*Check, error) {
+	return s.memory.Get(key)
+}
+
+// All returns every check in the store.
+func (s *boltStore) All() ([]*Check, error) {
+	return s.memory.All()
+}
+
+// Remove deletes the element identified by key and its state.
+func (s *boltStore) Remove(key string) error {
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket(boltChecksBucket).Delete([]byte(key)); err != nil {
+			return err
+		}
+		return tx.Bucket(boltStatesBucket).Delete([]byte(key))
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.memory.Remove(key)
+}
+
+// Len returns the number of items in the store
+func (s *boltStore) Len() (int, error) {
+	return s.memory.Len()
+}
+
+func (s *boltStore) ScheduleAll(scheduler Scheduler) error {
+	return s.memory.ScheduleAll(scheduler)
+}
+
+// Log persists the state of the event's check. Checks unknown to the store are ignored.
+func (s *boltStore) Log(e *Event) {
+	state, err := json.Marshal(newCheckState(e.Check))
+	if err != nil {
+		return
+	}
+
+	s.db.Update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(boltChecksBucket).Get([]byte(e.Check.Key)) == nil {
+			return nil
+		}
+		return tx.Bucket(boltStatesBucket).Put([]byte(e.Check.Key), state)
+	})
+}
+
+// Close closes the underlying database.
+func (s *boltStore) Close() {
+	s.db.Close()
+}
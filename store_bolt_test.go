@@ -0,0 +1,101 @@
+package poller
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBoltStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "poller")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "poller.db")
+
+	s, err := NewBoltStore(path)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	check, _ := NewCheckFromJSON([]byte(testJsonHttpCheck))
+	if err := s.Add(check); err != nil {
+		t.Error(err)
+	}
+
+	downSince := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	check.DownSince = downSince
+	check.WasUpFor = time.Hour
+	check.Alerted = true
+	s.Log(NewEvent(check))
+	s.Close()
+
+	// A new store on the same database should restore the check and its state
+	s, err = NewBoltStore(path)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	defer s.Close()
+
+	check, _ = s.Get("connect_sensiolabs_com_api")
+	if check == nil {
+		t.Log("Get should have returned the persisted check")
+		t.FailNow()
+	}
+	if !check.DownSince.Equal(downSince) {
+		t.Errorf("DownSince should be %s. Got %s", downSince, check.DownSince)
+	}
+	if check.WasUpFor != time.Hour {
+		t.Error("WasUpFor should be 1h")
+	}
+	if !check.Alerted {
+		t.Error("Alerted should be true")
+	}
+
+	if err := s.Remove("connect_sensiolabs_com_api"); err != nil {
+		t.Error(err)
+	}
+	l, _ := s.Len()
+	if l != 0 {
+		t.Error("Store's length should be 0 after removal")
+	}
+}
+
+func TestBoltStoreAcknowledgement(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "poller.db")
+	s, err := NewBoltStore(path)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	c := NewConfig(s, NewSimpleScheduler())
+
+	check, _ := NewCheckFromJSON([]byte(testJsonHttpCheck))
+	check.Escalation = []EscalationStep{{After: 0, Alerters: []string{"chat"}}}
+	if err := c.Add(check); err != nil {
+		t.Error(err)
+	}
+	NewEvent(check).Down()
+	if !c.Acknowledge(check) {
+		t.Error("Acknowledge() should be true when the check is down")
+	}
+	s.Close()
+
+	// The acknowledgement survives a restart, so the escalation does not resume
+	s, err = NewBoltStore(path)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	defer s.Close()
+	restored, _ := s.Get(check.Key)
+	if restored == nil || !restored.Acknowledged || restored.Escalated != 1 {
+		t.Errorf("Acknowledgement should be restored. Got %v", restored)
+	}
+}
@@ -0,0 +1,162 @@
+package poller
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// A fileStore keeps its checks in memory and persists them to a JSON file after each change.
+// The file contains a JSON array of checks, in the same format accepted by NewCheckFromJSON.
+type fileStore struct {
+	path   string
+	memory *inMemoryStore
+	mu     sync.Mutex // serializes writes to the file
+}
+
+// Instantiates a new file backed Store. Checks already persisted in path are loaded.
+// If the file does not exist yet, the store is empty and the file is created on the first change.
+func NewFileStore(path string) (Store, error) {
+	s := &fileStore{path: path, memory: &inMemoryStore{list: make(map[string]*Check)}}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *fileStore) load() error {
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for _, v := range raw {
+		check, err := NewCheckFromJSON(v)
+		if err != nil {
+			return err
+		}
+		s.memory.Add(check)
+	}
+
+	return nil
+}
+
+// Returns the checks of the store, except the one identified by key
+func (s *fileStore) allExcept(key string) ([]*Check, error) {
+	all, err := s.memory.All()
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]*Check, 0, len(all))
+	for _, check := range all {
+		if check.Key != key {
+			checks = append(checks, check)
+		}
+	}
+
+	return checks, nil
+}
+
+// Writes checks to a temporary file which is then renamed over the store's file.
+// The rename is atomic so a crash never leaves a truncated file behind.
+func (s *fileStore) persist(checks []*Check) error {
+	buffer := new(bytes.Buffer)
+	buffer.WriteString("[")
+	for i, check := range checks {
+		data, err := check.JSON()
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		buffer.Write(data)
+	}
+	buffer.WriteString("]")
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(buffer.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Add an element to the store and persist it.
+// The element is only added once persisted, so that the store never holds checks its file does not.
+func (s *fileStore) Add(check *Check) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checks, err := s.allExcept(check.Key)
+	if err != nil {
+		return err
+	}
+	if err := s.persist(append(checks, check)); err != nil {
+		return err
+	}
+
+	return s.memory.Add(check)
+}
+
+// Returns the element key from the store. If key is not present, nil is returned.
+func (s *fileStore) Get(key string) (*Check, error) {
+	return s.memory.Get(key)
+}
+
+// All returns every check in the store.
+func (s *fileStore) All() ([]*Check, error) {
+	return s.memory.All()
+}
+
+// Remove deletes the element identified by key and persist the change.
+// The element is only removed once the change is persisted.
+func (s *fileStore) Remove(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checks, err := s.allExcept(key)
+	if err != nil {
+		return err
+	}
+	if err := s.persist(checks); err != nil {
+		return err
+	}
+
+	return s.memory.Remove(key)
+}
+
+// Len returns the number of items in the store
+func (s *fileStore) Len() (int, error) {
+	return s.memory.Len()
+}
+
+func (s *fileStore) ScheduleAll(scheduler Scheduler) error {
+	return s.memory.ScheduleAll(scheduler)
+}
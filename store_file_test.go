@@ -0,0 +1,95 @@
+package poller
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "poller")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checks.json")
+
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+
+	l, _ := s.Len()
+	if l != 0 {
+		t.Error("Store's length should be 0")
+	}
+
+	check, _ := NewCheckFromJSON([]byte(testJsonHttpCheck))
+	if err := s.Add(check); err != nil {
+		t.Error(err)
+	}
+
+	// A new store on the same file should load the persisted check
+	s, err = NewFileStore(path)
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	check, _ = s.Get("connect_sensiolabs_com_api")
+	if check == nil {
+		t.Log("Get should have returned the persisted check")
+		t.FailNow()
+	}
+	if check.Config.GetString("url") != "https://connect.sensiolabs.com/api/" {
+		t.Error("Persisted check's url is wrong")
+	}
+
+	if err := s.Remove("connect_sensiolabs_com_api"); err != nil {
+		t.Error(err)
+	}
+	s, _ = NewFileStore(path)
+	l, _ = s.Len()
+	if l != 0 {
+		t.Error("Store's length should be 0 after removal")
+	}
+}
+
+func TestFileStorePersistFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "poller")
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := NewFileStore(filepath.Join(dir, "checks.json"))
+	if err != nil {
+		t.Log(err)
+		t.FailNow()
+	}
+	check, _ := NewCheckFromJSON([]byte(testJsonHttpCheck))
+	if err := s.Add(check); err != nil {
+		t.Error(err)
+	}
+
+	// The store's file can no longer be written
+	os.RemoveAll(dir)
+
+	other, _ := NewCheck("foobar", "10s", false, "", false, make(map[string]interface{}))
+	if err := s.Add(other); err == nil {
+		t.Error("Add() should fail when the check cannot be persisted")
+	}
+	if c, _ := s.Get("foobar"); c != nil {
+		t.Error("A check which could not be persisted should not be added")
+	}
+
+	if err := s.Remove("connect_sensiolabs_com_api"); err == nil {
+		t.Error("Remove() should fail when the removal cannot be persisted")
+	}
+	if c, _ := s.Get("connect_sensiolabs_com_api"); c == nil {
+		t.Error("A check which removal could not be persisted should not be removed")
+	}
+}
@@ -33,6 +33,19 @@ func (s *inMemoryStore) Get(key string) (*Check, error) {
 	return s.list[key], nil
 }
 
+// All returns every check in the list.
+func (s *inMemoryStore) All() ([]*Check, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checks := make([]*Check, 0, len(s.list))
+	for k := range s.list {
+		checks = append(checks, s.list[k])
+	}
+
+	return checks, nil
+}
+
 // Delete removes element identified by key from the list.
 func (s *inMemoryStore) Remove(key string) error {
 	s.mu.Lock()